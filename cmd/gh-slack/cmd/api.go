@@ -2,12 +2,9 @@ package cmd
 
 import (
 	"fmt"
-	"io"
-	"log"
 	"strings"
 
 	"github.com/cli/go-gh/v2/pkg/config"
-	"github.com/rneatherway/gh-slack/internal/slackclient"
 	"github.com/spf13/cobra"
 )
 
@@ -36,15 +33,11 @@ var apiCmd = &cobra.Command{
 			return err
 		}
 
-		logger := log.New(io.Discard, "", log.LstdFlags)
-		if verbose {
-			logger = log.Default()
-		}
-
-		client, err := slackclient.New(team, logger)
+		client, err := newSlackClient(cfg, team)
 		if err != nil {
 			return err
 		}
+		defer client.Close()
 
 		var verb, path string
 		if len(args) == 2 {
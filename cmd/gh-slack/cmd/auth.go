@@ -1,10 +1,14 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/url"
+	"os"
 
 	"github.com/cli/go-gh/v2/pkg/config"
+	"github.com/rneatherway/gh-slack/internal/slackclient"
 	"github.com/rneatherway/slack"
 	"github.com/spf13/cobra"
 )
@@ -12,14 +16,12 @@ import (
 var authCmd = &cobra.Command{
 	Use:   "auth [flags]",
 	Short: "Prints authentication information for the Slack API (treat output as secret)",
-	Long:  "Prints authentication information for the Slack API (treat output as secret).",
-	RunE: func(cmd *cobra.Command, args []string) error {
-		cfg, err := config.Read(nil)
-		if err != nil {
-			return err
-		}
+	Long: `Prints authentication information for the Slack API (treat output as secret).
 
-		team, err := getFlagOrElseConfig(cfg, cmd.Flags(), "team")
+To avoid the credentials ending up in your shell history or environment, use
+"gh-slack auth login" to store them in the system keyring instead.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		team, err := authTeam(cmd)
 		if err != nil {
 			return err
 		}
@@ -39,6 +41,10 @@ var authCmd = &cobra.Command{
 		return nil
 	},
 	Example: `  eval $(gh-slack auth [-t <team-name>])
+  gh-slack auth login [-t <team-name>]
+  gh-slack auth login --with-token < token.txt
+  gh-slack auth status
+  gh-slack auth logout
 
   # Example configuration (add to gh's configuration file at $HOME/.config/gh/config.yml):
   extensions:
@@ -46,10 +52,140 @@ var authCmd = &cobra.Command{
       team: foo`,
 }
 
+var authLoginCmd = &cobra.Command{
+	Use:   "login [flags]",
+	Short: "Stores credentials for the Slack API in the system keyring",
+	Long: `Stores credentials for the Slack API in the system keyring, where they are
+used in preference to those of the Slack desktop app. Where there is no system
+keyring, they are stored in an encrypted file instead.
+
+By default the credentials are taken from the Slack desktop app. With
+--with-token, a token is read from standard input instead, optionally followed
+on the next line by its cookies in the same form as SLACK_COOKIES.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		team, err := authTeam(cmd)
+		if err != nil {
+			return err
+		}
+
+		withToken, err := cmd.Flags().GetBool("with-token")
+		if err != nil {
+			return err
+		}
+
+		var credentials *slackclient.Credentials
+		if withToken {
+			input, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return err
+			}
+			credentials, err = slackclient.ParseCredentials(string(input))
+			if err != nil {
+				return err
+			}
+		} else {
+			credentials, err = slackclient.ResolveCredentials(team, slackclient.AuthOptions{Source: slackclient.AuthSourceDesktop})
+			if err != nil {
+				return err
+			}
+		}
+
+		identity, err := testCredentials(team, credentials)
+		if err != nil {
+			return err
+		}
+
+		where, err := slackclient.StoreCredentials(team, credentials)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Logged in to %s as %s, credentials stored in %s\n", identity.Team, identity.User, where)
+		return nil
+	},
+}
+
+var authStatusCmd = &cobra.Command{
+	Use:   "status [flags]",
+	Short: "Checks the stored credentials for the Slack API",
+	Long:  "Checks that the credentials stored by \"gh-slack auth login\" are still valid.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		team, err := authTeam(cmd)
+		if err != nil {
+			return err
+		}
+
+		credentials, err := slackclient.StoredCredentials(team)
+		if err != nil {
+			return err
+		}
+
+		identity, err := testCredentials(team, credentials)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Logged in to %s as %s, credentials stored in %s\n", identity.Team, identity.User, credentials.Source)
+		return nil
+	},
+}
+
+var authLogoutCmd = &cobra.Command{
+	Use:   "logout [flags]",
+	Short: "Removes the stored credentials for the Slack API",
+	Long:  "Removes the credentials stored by \"gh-slack auth login\".",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		team, err := authTeam(cmd)
+		if err != nil {
+			return err
+		}
+
+		err = slackclient.DeleteStoredCredentials(team)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Logged out of %s\n", team)
+		return nil
+	},
+}
+
+func authTeam(cmd *cobra.Command) (string, error) {
+	cfg, err := config.Read(nil)
+	if err != nil {
+		return "", err
+	}
+
+	return getFlagOrElseConfig(cfg, cmd.Flags(), "team")
+}
+
+// testCredentials checks the credentials with auth.test, returning who they
+// identify.
+func testCredentials(team string, credentials *slackclient.Credentials) (*slackclient.AuthTestResponse, error) {
+	client, err := slackclient.NewWithCredentials(team, credentials, newLogger())
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	identity, err := client.AuthTest()
+	if err != nil {
+		return nil, errors.Join(errors.New("the credentials are not valid"), err)
+	}
+
+	return identity, nil
+}
+
 func init() {
-	authCmd.Flags().StringP("team", "t", "", "Slack team name (required here or in config)")
+	authCmd.PersistentFlags().StringP("team", "t", "", "Slack team name (required here or in config)")
+	authLoginCmd.Flags().Bool("with-token", false, "Read a token from standard input instead of using the Slack desktop app")
+	authCmd.AddCommand(authLoginCmd, authStatusCmd, authLogoutCmd)
 	authCmd.SetHelpTemplate(authCmdUsageTemplate)
 	authCmd.SetUsageTemplate(authCmdUsageTemplate)
+	for _, cmd := range authCmd.Commands() {
+		cmd.SetHelpTemplate(sendCmdUsage)
+		cmd.SetUsageTemplate(sendCmdUsage)
+	}
 }
 
 const authCmdUsageTemplate string = `Usage:{{if .Runnable}}
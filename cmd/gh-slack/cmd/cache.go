@@ -0,0 +1,313 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/config"
+	"github.com/cli/go-gh/v2/pkg/tableprinter"
+	"github.com/cli/go-gh/v2/pkg/term"
+	"github.com/rneatherway/gh-slack/internal/slackclient"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache <command>",
+	Short: "Inspects and manages the local cache of Slack users and channels",
+	Long: `Inspects and manages the local cache of Slack users and channels.
+
+Each command applies to both users and channels, unless --users or --channels
+is given.`,
+	Example: `  gh-slack cache show -t <team-name> --channels
+  gh-slack cache refresh --background -t <team-name>
+  gh-slack cache clear -t <team-name> --users
+  gh-slack cache path -t <team-name>`,
+}
+
+var cacheShowCmd = &cobra.Command{
+	Use:   "show [flags]",
+	Short: "Prints the cached users and channels",
+	Long:  "Prints the cached users and channels, with the time each was fetched.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, users, channels, err := cacheClient(cmd.Flags(), true)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		asJSON, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			return err
+		}
+
+		cache, err := cacheContents(client, users, channels)
+		if err != nil {
+			return err
+		}
+
+		if asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(cache)
+		}
+
+		return printCache(newTablePrinter(), cache)
+	},
+}
+
+var cacheRefreshCmd = &cobra.Command{
+	Use:   "refresh [flags]",
+	Short: "Downloads all users and channels into the cache",
+	Long: `Downloads all users and channels into the cache.
+
+This can take a while in large workspaces, so --background can be used to do
+it ahead of time without waiting, for example so that the first use of "send"
+doesn't stall. The background process's output is written to
+$XDG_STATE_HOME/gh-slack/cache_refresh.log.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		background, err := cmd.Flags().GetBool("background")
+		if err != nil {
+			return err
+		}
+
+		if background {
+			return refreshInBackground(cmd)
+		}
+
+		client, users, channels, err := cacheClient(cmd.Flags(), false)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		return refreshCache(client, users, channels)
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear [flags]",
+	Short: "Removes cached users and channels",
+	Long:  "Removes cached users and channels, so that they are fetched again when next needed.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, users, channels, err := cacheClient(cmd.Flags(), true)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		return client.ClearCache(users, channels)
+	},
+}
+
+var cachePathCmd = &cobra.Command{
+	Use:   "path [flags]",
+	Short: "Prints the location of the cache",
+	Long:  "Prints the location of the cache file or database.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, _, _, err := cacheClient(cmd.Flags(), true)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		fmt.Println(client.CachePath())
+		return nil
+	},
+}
+
+// cacheClient returns a client for the team given by the flags or config, and
+// which parts of the cache the command should apply to. Offline clients don't
+// need credentials.
+func cacheClient(flags *pflag.FlagSet, offline bool) (*slackclient.SlackClient, bool, bool, error) {
+	cfg, err := config.Read(nil)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	team, err := getFlagOrElseConfig(cfg, flags, "team")
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	users, err := flags.GetBool("users")
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	channels, err := flags.GetBool("channels")
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	if !users && !channels {
+		users, channels = true, true
+	}
+
+	var client *slackclient.SlackClient
+	if offline {
+		client, err = newOfflineSlackClient(cfg, team)
+	} else {
+		client, err = newSlackClient(cfg, team)
+	}
+
+	return client, users, channels, err
+}
+
+// cacheContents returns the parts of the cache selected by users and channels.
+func cacheContents(client *slackclient.SlackClient, users, channels bool) (slackclient.Cache, error) {
+	cache, err := client.CacheContents()
+	if err != nil {
+		return cache, err
+	}
+
+	if !users {
+		cache.Users, cache.Bots = nil, nil
+	}
+	if !channels {
+		cache.Channels = nil
+	}
+
+	return cache, nil
+}
+
+func printCache(t tableprinter.TablePrinter, cache slackclient.Cache) error {
+	t.AddHeader([]string{"TYPE", "ID", "NAME", "FETCHED"})
+	for _, user := range sortedByName(cache.Users, func(u slackclient.CachedUser) string { return u.Name }) {
+		addCacheRow(t, "user", user.ID, user.Name, user.FetchedAt)
+	}
+	for _, bot := range sortedByName(cache.Bots, func(b slackclient.CachedBot) string { return b.Name }) {
+		addCacheRow(t, "bot", bot.ID, bot.Name, bot.FetchedAt)
+	}
+	for _, channel := range sortedByName(cache.Channels, func(c slackclient.CachedChannel) string { return c.Name }) {
+		addCacheRow(t, "channel", channel.ID, channel.Name, channel.FetchedAt)
+	}
+
+	return t.Render()
+}
+
+func refreshCache(client *slackclient.SlackClient, users, channels bool) error {
+	if users {
+		err := client.RefreshUsers()
+		if err != nil {
+			return err
+		}
+	}
+
+	if channels {
+		err := client.RefreshChannels()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// refreshLogPath is where the output of a background refresh is written.
+func refreshLogPath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "cache_refresh.log"), nil
+}
+
+// refreshInBackground runs "cache refresh" again with the same flags as a
+// detached process, in a session of its own so that it outlives the terminal,
+// with its output written to a log.
+func refreshInBackground(cmd *cobra.Command) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	args := []string{"cache", "refresh"}
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if f.Name != "background" {
+			args = append(args, fmt.Sprintf("--%s=%s", f.Name, f.Value))
+		}
+	})
+
+	logPath, err := refreshLogPath()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(logPath), 0700)
+	if err != nil {
+		return err
+	}
+
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	child := exec.Command(executable, args...)
+	child.Stdout = logFile
+	child.Stderr = logFile
+	detach(child)
+	err = child.Start()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Refreshing cache in the background (pid %d), logging to %s\n", child.Process.Pid, logPath)
+	return child.Process.Release()
+}
+
+func newTablePrinter() tableprinter.TablePrinter {
+	t := term.FromEnv()
+	width, _, err := t.Size()
+	if err != nil {
+		width = 80
+	}
+
+	return tableprinter.New(t.Out(), t.IsTerminalOutput(), width)
+}
+
+func addCacheRow(t tableprinter.TablePrinter, kind, id, name string, fetchedAt time.Time) {
+	fetched := "never"
+	if !fetchedAt.IsZero() {
+		fetched = fetchedAt.Local().Format(time.DateTime)
+	}
+
+	t.AddField(kind)
+	t.AddField(id)
+	t.AddField(name)
+	t.AddField(fetched)
+	t.EndRow()
+}
+
+func sortedByName[T any](m map[string]T, name func(T) string) []T {
+	values := make([]T, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+
+	sort.Slice(values, func(i, j int) bool {
+		return name(values[i]) < name(values[j])
+	})
+
+	return values
+}
+
+func init() {
+	cacheCmd.PersistentFlags().StringP("team", "t", "", "Slack team name (required here or in config)")
+	cacheCmd.PersistentFlags().Bool("users", false, "Only apply to users (and bots)")
+	cacheCmd.PersistentFlags().Bool("channels", false, "Only apply to channels")
+	cacheShowCmd.Flags().Bool("json", false, "Output the cache as JSON")
+	cacheRefreshCmd.Flags().Bool("background", false, "Refresh in a background process and return immediately")
+
+	cacheCmd.SetUsageTemplate(sendCmdUsage)
+	cacheCmd.SetHelpTemplate(sendCmdUsage)
+	cacheCmd.AddCommand(cacheShowCmd, cacheRefreshCmd, cacheClearCmd, cachePathCmd)
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/tableprinter"
+	"github.com/rneatherway/gh-slack/internal/mocks"
+	"github.com/rneatherway/gh-slack/internal/slackclient"
+	"github.com/spf13/pflag"
+)
+
+// withCacheDirs keeps the cache and gh's configuration in temporary
+// directories, and returns the data directory.
+func withCacheDirs(t *testing.T) string {
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	t.Setenv("GH_CONFIG_DIR", t.TempDir())
+	t.Setenv("TMPDIR", t.TempDir())
+	return dataHome
+}
+
+func newCacheFlags(t *testing.T, args ...string) *pflag.FlagSet {
+	flags := pflag.NewFlagSet("cache", pflag.ContinueOnError)
+	flags.StringP("team", "t", "", "")
+	flags.Bool("users", false, "")
+	flags.Bool("channels", false, "")
+	err := flags.Parse(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return flags
+}
+
+// cachedClient returns an offline client for the team, with a user and a
+// channel in its cache.
+func cachedClient(t *testing.T, team string) *slackclient.SlackClient {
+	client, err := slackclient.NewOffline(team, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	err = client.ImportUsers([]slackclient.User{{ID: "U1", Name: "alice"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.ImportChannels([]slackclient.Channel{{ID: "C1", Name: "ops", Is_Channel: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestCacheShow(t *testing.T) {
+	withCacheDirs(t)
+	client := cachedClient(t, "acme")
+
+	for _, test := range []struct {
+		name            string
+		users, channels bool
+		expected        []string
+		unexpected      []string
+	}{
+		{"all", true, true, []string{"user", "alice", "channel", "ops"}, nil},
+		{"users", true, false, []string{"alice"}, []string{"ops"}},
+		{"channels", false, true, []string{"ops"}, []string{"alice"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cache, err := cacheContents(client, test.users, test.channels)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var out bytes.Buffer
+			err = printCache(tableprinter.New(&out, false, 80), cache)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, expected := range test.expected {
+				if !strings.Contains(out.String(), expected) {
+					t.Errorf("expected %q in the output:\n%s", expected, out.String())
+				}
+			}
+			for _, unexpected := range test.unexpected {
+				if strings.Contains(out.String(), unexpected) {
+					t.Errorf("expected no %q in the output:\n%s", unexpected, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestCacheRefresh(t *testing.T) {
+	withCacheDirs(t)
+
+	var calls []string
+	mockClient := &mocks.MockClient{}
+	mockClient.MockResponses(map[string]string{
+		"auth.test":          `{"ok":true,"team_id":"T1"}`,
+		"users.list":         `{"ok":true,"members":[{"id":"U1","name":"alice"}]}`,
+		"conversations.list": `{"ok":true,"channels":[{"id":"C1","name":"ops","is_channel":true}]}`,
+	}, &calls)
+
+	client, err := slackclient.Null("test", mockClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	err = refreshCache(client, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := client.CacheContents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cache.Channels) != 1 || len(cache.Users) != 0 {
+		t.Errorf("expected only the channels to be refreshed, got %+v", cache)
+	}
+	for _, call := range calls {
+		if call == "users.list" {
+			t.Error("expected the users not to be fetched")
+		}
+	}
+
+	err = refreshCache(client, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err = client.CacheContents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cache.Users["U1"].Name != "alice" {
+		t.Errorf("expected the users to be refreshed, got %+v", cache.Users)
+	}
+}
+
+func TestCacheClear(t *testing.T) {
+	withCacheDirs(t)
+	cachedClient(t, "acme").Close()
+
+	client, users, channels, err := cacheClient(newCacheFlags(t, "-t", "acme", "--users"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	err = client.ClearCache(users, channels)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := client.CacheContents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cache.Users) != 0 {
+		t.Errorf("expected the users to be cleared, got %+v", cache.Users)
+	}
+	if len(cache.Channels) != 1 {
+		t.Errorf("expected the channels to be kept, got %+v", cache.Channels)
+	}
+}
+
+func TestCachePath(t *testing.T) {
+	dataHome := withCacheDirs(t)
+
+	client, users, channels, err := cacheClient(newCacheFlags(t, "-t", "acme"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if !users || !channels {
+		t.Error("expected both users and channels to be selected by default")
+	}
+
+	teamDir := filepath.Join(dataHome, "gh-slack", "teams", "acme")
+	if filepath.Dir(client.CachePath()) != teamDir {
+		t.Errorf("expected the cache to be in %s, got %s", teamDir, client.CachePath())
+	}
+}
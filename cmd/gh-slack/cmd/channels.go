@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/cli/go-gh/v2/pkg/config"
+	"github.com/rneatherway/gh-slack/internal/slackclient"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var channelsCmd = &cobra.Command{
+	Use:   "channels <command>",
+	Short: "Lists the channels in a Slack workspace",
+	Long:  "Lists the channels in a Slack workspace.",
+	Example: `  gh-slack channels list -t <team-name>
+  gh-slack channels list --member --topic deploy
+  gh-slack channels list --archived --private --json`,
+}
+
+// channelSummary is a channel as output by "channels list --json".
+type channelSummary struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Private    bool   `json:"private"`
+	Archived   bool   `json:"archived"`
+	Member     bool   `json:"member"`
+	NumMembers int    `json:"num_members"`
+	Topic      string `json:"topic"`
+	Purpose    string `json:"purpose"`
+}
+
+// channelFilter selects channels. Each filter that is set must match.
+type channelFilter struct {
+	member   bool
+	private  bool
+	archived bool
+	topic    *regexp.Regexp
+}
+
+func (f channelFilter) matches(channel slackclient.Channel) bool {
+	return (!f.member || channel.Is_Member) &&
+		(!f.private || channel.Is_Private) &&
+		(!f.archived || channel.Is_Archived) &&
+		(f.topic == nil || f.topic.MatchString(channel.Topic.Value) || f.topic.MatchString(channel.Purpose.Value))
+}
+
+// filterChannels returns the channels matching the filter, sorted by name.
+func filterChannels(channels []slackclient.Channel, filter channelFilter) []channelSummary {
+	summaries := make([]channelSummary, 0, len(channels))
+	for _, channel := range channels {
+		if !filter.matches(channel) {
+			continue
+		}
+
+		summaries = append(summaries, channelSummary{
+			ID:         channel.ID,
+			Name:       channel.Name,
+			Private:    channel.Is_Private,
+			Archived:   channel.Is_Archived,
+			Member:     channel.Is_Member,
+			NumMembers: channel.NumMembers,
+			Topic:      channel.Topic.Value,
+			Purpose:    channel.Purpose.Value,
+		})
+	}
+
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Name < summaries[j].Name })
+	return summaries
+}
+
+func channelFilterFromFlags(flags *pflag.FlagSet) (channelFilter, error) {
+	var filter channelFilter
+	var err error
+	for name, value := range map[string]*bool{"member": &filter.member, "private": &filter.private, "archived": &filter.archived} {
+		*value, err = flags.GetBool(name)
+		if err != nil {
+			return filter, err
+		}
+	}
+
+	topic, err := flags.GetString("topic")
+	if err != nil {
+		return filter, err
+	}
+	if topic != "" {
+		filter.topic, err = regexp.Compile("(?i)" + topic)
+		if err != nil {
+			return filter, err
+		}
+	}
+
+	return filter, nil
+}
+
+var channelsListCmd = &cobra.Command{
+	Use:   "list [flags]",
+	Short: "Prints the channels, optionally filtered",
+	Long:  "Prints the public and private channels visible to you, optionally filtered.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter, err := channelFilterFromFlags(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		asJSON, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			return err
+		}
+
+		client, err := directoryClient(cmd.Flags())
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		channels, err := client.ListChannels(filter.archived)
+		if err != nil {
+			return err
+		}
+
+		summaries := filterChannels(channels, filter)
+
+		if asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(summaries)
+		}
+
+		t := newTablePrinter()
+		t.AddHeader([]string{"NAME", "ID", "MEMBERS", "TYPE", "TOPIC"})
+		for _, channel := range summaries {
+			t.AddField("#" + channel.Name)
+			t.AddField(channel.ID)
+			t.AddField(strconv.Itoa(channel.NumMembers))
+			t.AddField(channelType(channel))
+			t.AddField(channel.Topic)
+			t.EndRow()
+		}
+		return t.Render()
+	},
+}
+
+func channelType(channel channelSummary) string {
+	kind := "public"
+	if channel.Private {
+		kind = "private"
+	}
+	if channel.Archived {
+		kind += ", archived"
+	}
+	return kind
+}
+
+// directoryClient returns a client for the team given by --team, or in the
+// configuration.
+func directoryClient(flags *pflag.FlagSet) (*slackclient.SlackClient, error) {
+	cfg, err := config.Read(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	team, err := getFlagOrElseConfig(cfg, flags, "team")
+	if err != nil {
+		return nil, err
+	}
+
+	return newSlackClient(cfg, team)
+}
+
+func init() {
+	channelsCmd.PersistentFlags().StringP("team", "t", "", "Slack team name (required here or in config)")
+	channelsListCmd.Flags().Bool("member", false, "Only channels you are a member of")
+	channelsListCmd.Flags().Bool("private", false, "Only private channels")
+	channelsListCmd.Flags().Bool("archived", false, "Only archived channels")
+	channelsListCmd.Flags().String("topic", "", "Only channels whose topic or purpose matches this regular expression (case-insensitive)")
+	channelsListCmd.Flags().Bool("json", false, "Output the channels as JSON")
+
+	channelsCmd.SetUsageTemplate(sendCmdUsage)
+	channelsCmd.SetHelpTemplate(sendCmdUsage)
+	channelsCmd.AddCommand(channelsListCmd)
+}
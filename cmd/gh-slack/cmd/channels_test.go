@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/rneatherway/gh-slack/internal/slackclient"
+)
+
+func TestFilterChannels(t *testing.T) {
+	channels := []slackclient.Channel{
+		{ID: "C3", Name: "ops", Is_Member: true, Topic: slackclient.ChannelText{Value: "Deploys and incidents"}},
+		{ID: "C1", Name: "dev", Is_Member: true},
+		{ID: "C2", Name: "secret", Is_Private: true, Purpose: slackclient.ChannelText{Value: "deploy keys"}},
+		{ID: "C4", Name: "old-ops", Is_Archived: true, Topic: slackclient.ChannelText{Value: "deploys"}},
+	}
+
+	for _, test := range []struct {
+		name     string
+		filter   channelFilter
+		expected []string
+	}{
+		{"all", channelFilter{}, []string{"dev", "old-ops", "ops", "secret"}},
+		{"member", channelFilter{member: true}, []string{"dev", "ops"}},
+		{"private", channelFilter{private: true}, []string{"secret"}},
+		{"archived", channelFilter{archived: true}, []string{"old-ops"}},
+		{"topic", channelFilter{topic: regexp.MustCompile("(?i)^deploy")}, []string{"old-ops", "ops", "secret"}},
+		{"member topic", channelFilter{member: true, topic: regexp.MustCompile("(?i)deploy")}, []string{"ops"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			actual := filterChannels(channels, test.filter)
+			names := make([]string, len(actual))
+			for i, channel := range actual {
+				names[i] = channel.Name
+			}
+			if len(names) != len(test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, names)
+			}
+			for i := range names {
+				if names[i] != test.expected[i] {
+					t.Fatalf("expected %v, got %v", test.expected, names)
+				}
+			}
+		})
+	}
+}
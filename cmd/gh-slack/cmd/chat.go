@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/config"
+	"github.com/rneatherway/gh-slack/internal/slackclient"
+	"github.com/spf13/cobra"
+)
+
+var chatCmd = &cobra.Command{
+	Use:   "chat [flags]",
+	Short: "Starts an interactive session with a bot in a Slack channel",
+	Long: `Starts an interactive session with a bot in a Slack channel.
+
+Each line entered at the prompt is sent to the channel as a message, and
+messages from the bot are printed as they arrive. A single connection is kept
+open for the whole session, so this avoids the rate limits hit by calling
+"send --wait" repeatedly. Enter /quit or press Ctrl-D to exit.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Read(nil)
+		if err != nil {
+			return err
+		}
+
+		channelName, err := getFlagOrElseConfig(cfg, cmd.Flags(), "channel")
+		if err != nil {
+			return err
+		}
+
+		team, err := getFlagOrElseConfig(cfg, cmd.Flags(), "team")
+		if err != nil {
+			return err
+		}
+
+		bot, err := getFlagOrElseConfig(cfg, cmd.Flags(), "bot")
+		if err != nil {
+			return err
+		}
+
+		transportFlag, err := cmd.Flags().GetString("transport")
+		if err != nil {
+			return err
+		}
+
+		transport, err := slackclient.ParseTransport(transportFlag)
+		if err != nil {
+			return err
+		}
+
+		client, err := newSlackClient(cfg, team)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		p, err := newPrompt(fmt.Sprintf("#%s> ", channelName))
+		if err != nil {
+			return err
+		}
+		defer p.Close()
+
+		return chat(client, p, channelName, bot, transport)
+	},
+	Example: `  gh-slack chat -t <team-name> -c <channel-name> -b <bot-name>
+  gh-slack chat # If team, channel and bot are specified in config
+` + sendConfigEample,
+}
+
+// reconnectDelay is how long to wait before reconnecting a dropped session.
+// Both rtm.connect and apps.connections.open are Tier 1 APIs, so this should
+// not be too short.
+var reconnectDelay = 10 * time.Second
+
+// chat runs an interactive session with a bot in a Slack channel, reading the
+// messages to send from p.
+func chat(client *slackclient.SlackClient, p *prompt, channelName, bot string, transport slackclient.Transport) error {
+	channelID, err := client.ChannelIDForName(channelName)
+	if err != nil {
+		return err
+	}
+
+	botIdentity, err := client.ResolveBotIdentity(bot)
+	if err != nil {
+		return err
+	}
+
+	stream, err := client.ConnectToEventStream(transport)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	go func() {
+		defer close(done)
+		for {
+			err := client.FollowMessagesFromBot(ctx, stream, channelID, botIdentity, p)
+			stream.Close()
+			if ctx.Err() != nil {
+				return
+			}
+
+			for {
+				fmt.Fprintf(p, "Connection lost (%s), reconnecting in %s...\n", err, reconnectDelay)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(reconnectDelay):
+				}
+
+				stream, err = client.ConnectToEventStream(transport)
+				if err == nil {
+					break
+				}
+			}
+		}
+	}()
+
+	for {
+		line, err := p.ReadLine()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		line = strings.TrimSpace(line)
+		switch line {
+		case "":
+			continue
+		case "/quit", "/exit":
+			return nil
+		}
+
+		_, err = client.SendMessage(channelID, line)
+		if err != nil {
+			fmt.Fprintf(p, "Error: %s\n", err)
+		}
+	}
+}
+
+func init() {
+	chatCmd.Flags().StringP("channel", "c", "", "Channel name to chat in (required here or in config)")
+	chatCmd.Flags().StringP("team", "t", "", "Slack team name (required here or in config)")
+	chatCmd.Flags().StringP("bot", "b", "", "User id (most reliable), bot id, app id, profile name or username to print responses from (required here or in config)")
+	chatCmd.Flags().String("transport", string(slackclient.TransportAuto), "How to listen for responses: auto, rtm or socket (Socket Mode, requires $SLACK_APP_TOKEN)")
+	chatCmd.SetUsageTemplate(sendCmdUsage)
+	chatCmd.SetHelpTemplate(sendCmdUsage)
+}
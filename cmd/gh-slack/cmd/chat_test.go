@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rneatherway/gh-slack/internal/mocks"
+	"github.com/rneatherway/gh-slack/internal/slackclient"
+	"nhooyr.io/websocket"
+)
+
+// syncBuffer is a bytes.Buffer that can be written to by the session's
+// goroutines while the test reads it.
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.String()
+}
+
+func TestChat(t *testing.T) {
+	delay := reconnectDelay
+	reconnectDelay = time.Millisecond
+	t.Cleanup(func() { reconnectDelay = delay })
+
+	// The first connection drops after a message from the bot, and the second
+	// stays open once it has sent another.
+	var connections int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		connections++
+		text := "first"
+		if connections > 1 {
+			text = "second"
+		}
+		err = conn.Write(r.Context(), websocket.MessageText, []byte(
+			`{"type":"message","channel":"C1","user":"U9","text":"`+text+`","ts":"1709596900.000001","bot_profile":{"name":"deploybot"}}`))
+		if err != nil {
+			t.Error(err)
+		}
+
+		if connections == 1 {
+			conn.Close(websocket.StatusGoingAway, "")
+			return
+		}
+		_, _, err = conn.Read(r.Context())
+		if websocket.CloseStatus(err) != websocket.StatusNormalClosure {
+			t.Errorf("expected the connection to be closed, got %v", err)
+		}
+	}))
+	defer server.Close()
+
+	var calls []string
+	mockClient := &mocks.MockClient{}
+	mockClient.MockResponses(map[string]string{
+		"auth.test":          `{"ok":true,"team_id":"T1"}`,
+		"conversations.list": `{"ok":true,"channels":[{"id":"C1","name":"ops","is_channel":true}]}`,
+		"rtm.connect":        `{"ok":true,"url":"ws` + strings.TrimPrefix(server.URL, "http") + `"}`,
+		"chat.postMessage":   `{"ok":true,"channel":"C1","ts":"1709596901.000001"}`,
+	}, &calls)
+
+	client, err := slackclient.Null("test", mockClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	input, typed := io.Pipe()
+	output := &syncBuffer{}
+	done := make(chan error)
+	go func() {
+		done <- chat(client, newLinePrompt(input, output), "ops", "deploybot", slackclient.TransportRTM)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(output.String(), "second") {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for the bot's messages:\n%s", output)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	_, err = io.WriteString(typed, "hello\n\n/quit\nignored\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for /quit to end the session")
+	}
+
+	out := output.String()
+	for _, expected := range []string{"first", "Connection lost", "second"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected %q in the output:\n%s", expected, out)
+		}
+	}
+
+	var connects, posts int
+	for _, call := range calls {
+		switch call {
+		case "rtm.connect":
+			connects++
+		case "chat.postMessage":
+			posts++
+		}
+	}
+	if connects != 2 {
+		t.Errorf("expected to reconnect once, got %d connections", connects)
+	}
+	if posts != 1 {
+		t.Errorf("expected only the line before /quit to be sent, got %d messages", posts)
+	}
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/cli/go-gh/v2/pkg/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// configKeys are the keys that gh-slack reads from its configuration, in the
+// order that "config list" shows them.
+var configKeys = []string{
+	"team",
+	"team_id",
+	"channel",
+	"bot",
+	"timeout",
+	"auth",
+	"token_file",
+	"user_cache_ttl",
+	"channel_cache_ttl",
+	"cache_backend",
+	"archive",
+	"name_style",
+	"github_org",
+	"github_users_file",
+}
+
+var configCmd = &cobra.Command{
+	Use:     "config <command>",
+	Short:   "Shows the gh-slack configuration",
+	Long:    "Shows the gh-slack configuration.",
+	Example: configExample,
+}
+
+const configExample = `  gh-slack config list
+  gh-slack config list --profile work --json
+
+  # Each key is read from the first of these sources that sets it:
+  #   flag    a command line flag
+  #   env     the GH_SLACK_<KEY> environment variable, e.g. GH_SLACK_CHANNEL
+  #   repo    .github/gh-slack.yml in the current repository
+  #   global  the selected profile, or extensions.slack, in gh's configuration`
+
+type configValue struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+var configListCmd = &cobra.Command{
+	Use:     "list [flags]",
+	Short:   "Prints the effective value of each configuration key and its source",
+	Long:    "Prints the effective value of each configuration key and where it came from.",
+	Example: configExample,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Read(nil)
+		if err != nil {
+			return err
+		}
+
+		values, err := listConfig(cfg, cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		asJSON, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			return err
+		}
+
+		if asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(values)
+		}
+
+		t := newTablePrinter()
+		t.AddHeader([]string{"KEY", "VALUE", "SOURCE"})
+		for _, value := range values {
+			t.AddField(value.Key)
+			t.AddField(value.Value)
+			t.AddField(value.Source)
+			t.EndRow()
+		}
+		return t.Render()
+	},
+}
+
+// listConfig returns the effective value of each key. Keys that are not set
+// have no source.
+func listConfig(cfg *config.Config, flags *pflag.FlagSet) ([]configValue, error) {
+	values := make([]configValue, 0, len(configKeys))
+	for _, key := range configKeys {
+		if flag := flags.Lookup(key); flag != nil && flag.Value.String() != "" {
+			values = append(values, configValue{key, flag.Value.String(), "flag"})
+			continue
+		}
+
+		value, source, err := lookupGHSlackConfigValue(cfg, key)
+		var notFound *config.KeyNotFoundError
+		if errors.As(err, &notFound) {
+			value, source = "", ""
+		} else if err != nil {
+			return nil, err
+		}
+
+		values = append(values, configValue{key, value, source})
+	}
+
+	return values, nil
+}
+
+func init() {
+	configListCmd.Flags().StringP("team", "t", "", "Slack team name")
+	configListCmd.Flags().StringP("channel", "c", "", "Channel name")
+	configListCmd.Flags().StringP("bot", "b", "", "Bot name")
+	configListCmd.Flags().Bool("json", false, "Output the configuration as JSON")
+
+	configCmd.SetUsageTemplate(sendCmdUsage)
+	configCmd.SetHelpTemplate(sendCmdUsage)
+	configCmd.AddCommand(configListCmd)
+}
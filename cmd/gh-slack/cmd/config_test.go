@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/config"
+	"github.com/spf13/pflag"
+)
+
+func TestListConfigSources(t *testing.T) {
+	err := loadRepoConfig(writeRepoConfig(t, "bot: deploy-bot\nchannel: deploys\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GH_SLACK_CHANNEL", "incidents")
+	t.Setenv("GH_SLACK_ARCHIVE", "true")
+	t.Setenv("GH_SLACK_TIMEOUT", "5m")
+	selectProfile(t, "work")
+
+	flags := pflag.NewFlagSet("list", pflag.ContinueOnError)
+	flags.StringP("team", "t", "", "")
+	err = flags.Parse([]string{"-t", "flagged"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := listConfig(config.ReadFromString(profilesConfig), flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]configValue{
+		"team":       {"team", "flagged", "flag"},
+		"channel":    {"channel", "incidents", "env"},
+		"bot":        {"bot", "deploy-bot", "repo"},
+		"timeout":    {"timeout", "5m", "env"},
+		"archive":    {"archive", "true", "env"},
+		"name_style": {"name_style", "real", "global"},
+		"github_org": {"github_org", "", ""},
+	}
+	for _, value := range values {
+		if e, ok := expected[value.Key]; ok && e != value {
+			t.Errorf("expected %+v, got %+v", e, value)
+		}
+	}
+}
+
+func TestConfigEnvOverridesProfile(t *testing.T) {
+	selectProfile(t, "work")
+	t.Setenv("GH_SLACK_TEAM", "fromenv")
+
+	team, err := getGHSlackConfigValue(config.ReadFromString(profilesConfig), "team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if team != "fromenv" {
+		t.Errorf("expected GH_SLACK_TEAM to be used, got %q", team)
+	}
+}
+
+func TestEmptyConfigEnvIsIgnored(t *testing.T) {
+	t.Setenv("GH_SLACK_TEAM", "")
+
+	team, source, err := lookupGHSlackConfigValue(config.ReadFromString(profilesConfig), "team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if team != "home" || source != "global" {
+		t.Errorf("expected an empty GH_SLACK_TEAM to be ignored, got %q from %s", team, source)
+	}
+}
+
+func TestTimeoutFromFlagOrConfig(t *testing.T) {
+	cfg := config.ReadFromString(profilesConfig)
+	newFlags := func(args ...string) *pflag.FlagSet {
+		flags := pflag.NewFlagSet("send", pflag.ContinueOnError)
+		flags.Duration("timeout", time.Minute, "")
+		err := flags.Parse(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return flags
+	}
+
+	for _, tt := range []struct {
+		env      string
+		args     []string
+		expected time.Duration
+	}{
+		{"", nil, time.Minute},
+		{"5m", nil, 5 * time.Minute},
+		{"5m", []string{"--timeout", "30s"}, 30 * time.Second},
+	} {
+		t.Setenv("GH_SLACK_TIMEOUT", tt.env)
+		if tt.env == "" {
+			os.Unsetenv("GH_SLACK_TIMEOUT")
+		}
+
+		timeout, err := getDurationFlagOrElseConfig(cfg, newFlags(tt.args...), "timeout")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if timeout != tt.expected {
+			t.Errorf("expected %s with GH_SLACK_TIMEOUT=%q and %q, got %s", tt.expected, tt.env, tt.args, timeout)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/config"
+	"github.com/rneatherway/gh-slack/internal/export"
+	"github.com/rneatherway/gh-slack/internal/slackclient"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [flags] <#channel>",
+	Short: "Exports the whole history of a Slack channel to a directory",
+	Long: `Exports the whole history of a Slack channel, including threads and files,
+to a directory in the layout of Slack's own workspace exports, with markdown
+for each day and thread and an index.
+
+Exporting to the same directory again resumes an interrupted export, or brings
+a finished one up to date.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Read(nil)
+		if err != nil {
+			return err
+		}
+
+		team, err := getFlagOrElseConfig(cfg, cmd.Flags(), "team")
+		if err != nil {
+			return err
+		}
+
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+
+		noFiles, err := cmd.Flags().GetBool("no-files")
+		if err != nil {
+			return err
+		}
+
+		nameStyle, err := cmd.Flags().GetString("name-style")
+		if err != nil {
+			return err
+		}
+
+		client, err := newSlackClient(cfg, team)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		if nameStyle != "" {
+			style, err := slackclient.ParseNameStyle(nameStyle)
+			if err != nil {
+				return err
+			}
+			client.WithNameStyle(style)
+		}
+
+		channelID := strings.TrimPrefix(args[0], "#")
+		if !channelIDRE.MatchString(channelID) {
+			channelID, err = client.ChannelIDForName(channelID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return export.Write(client, channelID, output, export.Options{
+			Files:    !noFiles,
+			Progress: os.Stderr,
+		})
+	},
+	Example: `  gh-slack export -t <team-name> '#ops'
+  gh-slack export -o ops-archive --no-files ops`,
+}
+
+func init() {
+	exportCmd.Flags().StringP("team", "t", "", "Slack team name (required here or in config)")
+	exportCmd.Flags().StringP("output", "o", "slack-export", "Directory to export to, or to resume exporting to")
+	exportCmd.Flags().Bool("no-files", false, "Don't download the files shared in the channel")
+	exportCmd.Flags().String("name-style", "", "How to name users in the markdown: handle, display, real or both (default handle, or name_style in config)")
+
+	exportCmd.SetUsageTemplate(sendCmdUsage)
+	exportCmd.SetHelpTemplate(sendCmdUsage)
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/cli/go-gh/v2/pkg/config"
+	"github.com/rneatherway/gh-slack/internal/gh"
+	"github.com/rneatherway/gh-slack/internal/usermap"
+)
+
+// loadGitHubUsers builds the mapping from Slack users to GitHub logins from
+// the github_users config (including the profile's and the repository's), the
+// YAML file and the members of the GitHub organization given by flags (or
+// github_users_file and github_org in the config). The organization needs the
+// GitHub API, so is skipped when offline. It returns nil if no mapping is
+// configured.
+func loadGitHubUsers(cfg *config.Config, file, org string, offline bool) (*usermap.Map, error) {
+	m := usermap.New()
+
+	// Mappings are added in increasing order of precedence, so that the
+	// profile's and then the repository's win.
+	type mappings struct {
+		cfg *config.Config
+		key []string
+	}
+	sources := []mappings{{cfg, []string{"extensions", "slack", "github_users"}}}
+	if profile != "" {
+		sources = append(sources, mappings{cfg, profileKey(profile, "github_users")})
+	}
+	if repoConfig != nil {
+		sources = append(sources, mappings{repoConfig, []string{"github_users"}})
+	}
+
+	var err error
+	for _, source := range sources {
+		slackUsers, err := source.cfg.Keys(source.key)
+		var notFound *config.KeyNotFoundError
+		if err != nil && !errors.As(err, &notFound) {
+			return nil, err
+		}
+
+		for _, slackUser := range slackUsers {
+			login, err := source.cfg.Get(append(source.key, slackUser))
+			if err != nil {
+				return nil, err
+			}
+			m.Add(slackUser, login)
+		}
+	}
+
+	if file == "" {
+		file, err = getOptionalGHSlackConfigValue(cfg, "github_users_file")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if file != "" {
+		err = m.AddFile(file)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if org == "" {
+		org, err = getOptionalGHSlackConfigValue(cfg, "github_org")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if org != "" && offline {
+		newLogger().Printf("Not matching Slack users with the members of %s while offline", org)
+	} else if org != "" {
+		emails, err := gh.OrgMemberEmails(org)
+		if err != nil {
+			return nil, err
+		}
+		m.AddEmails(emails)
+	}
+
+	if m.Len() == 0 {
+		return nil, nil
+	}
+
+	return m, nil
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/config"
+	"github.com/rneatherway/gh-slack/internal/slackclient"
+)
+
+func TestLoadGitHubUsers(t *testing.T) {
+	file := path.Join(t.TempDir(), "github-users.yml")
+	err := os.WriteFile(file, []byte("U2: hubot\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.ReadFromString(`
+extensions:
+  slack:
+    github_users:
+      jdoe: octocat
+`)
+
+	m, err := loadGitHubUsers(cfg, file, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, user := range []struct{ id, name, expected string }{
+		{"U1", "jdoe", "octocat"},
+		{"U2", "robot", "hubot"},
+	} {
+		login, _ := m.Login(&slackclient.User{ID: user.id, Name: user.name})
+		if login != user.expected {
+			t.Errorf("expected %s to be mapped to %q, got %q", user.name, user.expected, login)
+		}
+	}
+
+	m, err = loadGitHubUsers(config.ReadFromString(""), "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m != nil {
+		t.Error("expected no mapping when none is configured")
+	}
+}
+
+func TestLoadGitHubUsersOffline(t *testing.T) {
+	// The organization's members would be listed with gh, which must not be
+	// run offline.
+	t.Setenv("PATH", t.TempDir())
+
+	cfg := config.ReadFromString(`
+extensions:
+  slack:
+    github_org: acme
+    github_users:
+      jdoe: octocat
+`)
+
+	m, err := loadGitHubUsers(cfg, "", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	login, _ := m.Login(&slackclient.User{ID: "U1", Name: "jdoe"})
+	if login != "octocat" {
+		t.Errorf("expected the configured mapping to be used offline, got %q", login)
+	}
+
+	_, err = loadGitHubUsers(cfg, "", "", false)
+	if err == nil {
+		t.Error("expected listing the organization's members to fail without gh")
+	}
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/cli/go-gh/v2/pkg/config"
+	"github.com/rneatherway/gh-slack/internal/export"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import [flags] <zip or directory>",
+	Short: "Imports a Slack workspace export for reading offline",
+	Long: `Imports a Slack workspace export, as a ZIP file or unpacked into a directory,
+into the cache and message archive of a team. Its conversations can then be read
+with read --offline, and rendered or sent to GitHub, with no access to the Slack
+API.
+
+Directories written by export can be imported too.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Read(nil)
+		if err != nil {
+			return err
+		}
+
+		team, err := getFlagOrElseConfig(cfg, cmd.Flags(), "team")
+		if err != nil {
+			return err
+		}
+
+		fsys, closeFS, err := openExport(args[0])
+		if err != nil {
+			return err
+		}
+		defer closeFS()
+
+		client, err := newOfflineSlackClient(cfg, team)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		summary, err := export.Import(client, fsys, os.Stderr)
+		if err != nil {
+			return fmt.Errorf("failed to import %s: %w", args[0], err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Imported %d messages from %d conversations and %d users. Read them with gh-slack read --offline -t %s.\n",
+			summary.Messages, summary.Channels, summary.Users, team)
+		return nil
+	},
+	Example: `  gh-slack import -t <team-name> 'My Workspace Slack export.zip'
+  gh-slack import -t <team-name> slack-export`,
+}
+
+// openExport opens an export, either a ZIP file or a directory.
+func openExport(name string) (fs.FS, func() error, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if info.IsDir() {
+		return os.DirFS(name), func() error { return nil }, nil
+	}
+
+	r, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	return r, r.Close, nil
+}
+
+func init() {
+	importCmd.Flags().StringP("team", "t", "", "Slack team name (required here or in config)")
+
+	importCmd.SetUsageTemplate(sendCmdUsage)
+	importCmd.SetHelpTemplate(sendCmdUsage)
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/config"
+)
+
+// profile is the name of the profile selected with --profile, by the
+// repository's configuration, or by read from the team of the permalink. The
+// keys of a profile, under extensions.slack.profiles.<name>, take precedence
+// over those directly under extensions.slack.
+var profile string
+
+var profilesKey = []string{"extensions", "slack", "profiles"}
+
+// profileChosen reports whether the profile was chosen with --profile or
+// GH_SLACK_PROFILE, rather than by the repository's configuration.
+func profileChosen() bool {
+	return rootCmd.PersistentFlags().Changed("profile") || os.Getenv(configEnvVar("profile")) != ""
+}
+
+func profileKey(name string, key ...string) []string {
+	return append(append(append([]string{}, profilesKey...), name), key...)
+}
+
+// getProfileConfigValue returns the value of the key in the selected profile,
+// or false if there is no profile or the key is not set in it.
+func getProfileConfigValue(cfg *config.Config, key string) (string, bool, error) {
+	if profile == "" {
+		return "", false, nil
+	}
+
+	s, err := cfg.Get(profileKey(profile, key))
+	var notFound *config.KeyNotFoundError
+	if errors.As(err, &notFound) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+
+	return s, true, nil
+}
+
+// checkProfile returns an error if the selected profile is not configured.
+func checkProfile(cfg *config.Config) error {
+	if profile == "" {
+		return nil
+	}
+
+	names, err := profileNames(cfg)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if name == profile {
+			return nil
+		}
+	}
+
+	if len(names) == 0 {
+		return fmt.Errorf("profile %q is not configured, there are no profiles under %q",
+			profile, strings.Join(profilesKey, "."))
+	}
+	return fmt.Errorf("profile %q is not configured under %q, expected one of %q",
+		profile, strings.Join(profilesKey, "."), names)
+}
+
+func profileNames(cfg *config.Config) ([]string, error) {
+	names, err := cfg.Keys(profilesKey)
+	var notFound *config.KeyNotFoundError
+	if errors.As(err, &notFound) {
+		return nil, nil
+	}
+
+	return names, err
+}
+
+// profileForTeam returns the name of the profile whose team (or team_id)
+// matches, or "" if there is none.
+func profileForTeam(cfg *config.Config, team teamIdentity) (string, error) {
+	names, err := profileNames(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	for _, name := range names {
+		for _, match := range []struct{ key, value string }{{"team", team.domain}, {"team_id", team.id}} {
+			if match.value == "" {
+				continue
+			}
+
+			profileValue, err := cfg.Get(profileKey(name, match.key))
+			var notFound *config.KeyNotFoundError
+			if errors.As(err, &notFound) {
+				continue
+			} else if err != nil {
+				return "", err
+			}
+
+			if strings.EqualFold(profileValue, match.value) {
+				return name, nil
+			}
+		}
+	}
+
+	return "", nil
+}
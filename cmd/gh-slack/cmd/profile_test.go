@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/config"
+)
+
+const profilesConfig = `
+extensions:
+  slack:
+    team: home
+    channel: general
+    name_style: real
+    profiles:
+      work:
+        team: Acme
+        team_id: T0123
+        channel: ops
+      oss:
+        channel: dev
+`
+
+func selectProfile(t *testing.T, name string) {
+	profile = name
+	t.Cleanup(func() { profile = "" })
+}
+
+func TestProfileConfigValues(t *testing.T) {
+	cfg := config.ReadFromString(profilesConfig)
+
+	tests := []struct {
+		profile, key, expected string
+	}{
+		{"", "team", "home"},
+		{"", "channel", "general"},
+		{"work", "team", "Acme"},
+		{"work", "channel", "ops"},
+		{"work", "name_style", "real"},
+		{"oss", "team", "home"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.profile+"/"+tt.key, func(t *testing.T) {
+			selectProfile(t, tt.profile)
+
+			actual, err := getGHSlackConfigValue(cfg, tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCheckProfile(t *testing.T) {
+	cfg := config.ReadFromString(profilesConfig)
+
+	selectProfile(t, "work")
+	err := checkProfile(cfg)
+	if err != nil {
+		t.Error(err)
+	}
+
+	selectProfile(t, "play")
+	err = checkProfile(cfg)
+	if err == nil {
+		t.Error("expected an error for a profile that is not configured")
+	}
+}
+
+func TestProfileForTeam(t *testing.T) {
+	cfg := config.ReadFromString(profilesConfig)
+
+	for team, expected := range map[teamIdentity]string{
+		{domain: "acme"}:               "work",
+		{id: "T0123"}:                  "work",
+		{domain: "home"}:               "",
+		{domain: "other", id: "T9999"}: "",
+	} {
+		actual, err := profileForTeam(cfg, team)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Errorf("expected profile %q for team %s, got %q", expected, team, actual)
+		}
+	}
+}
+
+func TestProfileChosen(t *testing.T) {
+	t.Setenv("GH_SLACK_PROFILE", "")
+	os.Unsetenv("GH_SLACK_PROFILE")
+	if profileChosen() {
+		t.Error("expected no profile to be chosen")
+	}
+
+	t.Setenv("GH_SLACK_PROFILE", "work")
+	if !profileChosen() {
+		t.Error("expected GH_SLACK_PROFILE to choose the profile")
+	}
+}
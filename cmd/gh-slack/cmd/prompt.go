@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// maxHistory is the number of lines of chat history kept between sessions.
+const maxHistory = 500
+
+// prompt reads lines of input from stdin. When stdin is a terminal it supports
+// line editing and a history that persists between sessions, and output
+// written to it does not clobber the line being edited.
+type prompt struct {
+	io.Writer
+	readLine func() (string, error)
+	close    func() error
+}
+
+func (p *prompt) ReadLine() (string, error) {
+	return p.readLine()
+}
+
+func (p *prompt) Close() error {
+	return p.close()
+}
+
+func newPrompt(label string) (*prompt, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return newLinePrompt(os.Stdin, os.Stdout), nil
+	}
+
+	history, err := openHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		history.Close()
+		return nil, err
+	}
+
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, label)
+	if width, height, err := term.GetSize(fd); err == nil {
+		t.SetSize(width, height)
+	}
+	t.History = history
+
+	return &prompt{
+		Writer:   t,
+		readLine: t.ReadLine,
+		close: func() error {
+			return errors.Join(history.Close(), term.Restore(fd, state))
+		},
+	}, nil
+}
+
+// newLinePrompt reads lines from r without line editing, as when stdin is not
+// a terminal.
+func newLinePrompt(r io.Reader, w io.Writer) *prompt {
+	scanner := bufio.NewScanner(r)
+	return &prompt{
+		Writer: w,
+		readLine: func() (string, error) {
+			if scanner.Scan() {
+				return scanner.Text(), nil
+			}
+			if err := scanner.Err(); err != nil {
+				return "", err
+			}
+			return "", io.EOF
+		},
+		close: func() error { return nil },
+	}
+}
+
+// fileHistory is a term.History that appends every entry to a file, so that
+// it can be recalled in later sessions.
+type fileHistory struct {
+	entries []string // oldest first
+	file    *os.File
+}
+
+// stateDir is gh-slack's directory under $XDG_STATE_HOME, for files that
+// are kept between runs but aren't worth backing up.
+func stateDir() (string, error) {
+	stateHome := os.Getenv("XDG_STATE_HOME")
+	if stateHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		stateHome = filepath.Join(home, ".local", "state")
+	}
+
+	return filepath.Join(stateHome, "gh-slack"), nil
+}
+
+func historyPath() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "chat_history"), nil
+}
+
+func openHistory() (*fileHistory, error) {
+	path, err := historyPath()
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	var entries []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if line != "" {
+			entries = append(entries, line)
+		}
+	}
+
+	// Rewrite the file with only the entries we keep, so that it doesn't grow
+	// without bound.
+	if len(entries) > maxHistory {
+		entries = entries[len(entries)-maxHistory:]
+		err = os.WriteFile(path, []byte(strings.Join(entries, "\n")+"\n"), 0600)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileHistory{entries: entries, file: file}, nil
+}
+
+func (h *fileHistory) Add(entry string) {
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[1:]
+	}
+
+	// Failing to persist history shouldn't interrupt the session.
+	if _, err := fmt.Fprintln(h.file, entry); err != nil && verbose {
+		fmt.Fprintf(os.Stderr, "failed to save history: %s\n", err)
+	}
+}
+
+func (h *fileHistory) Len() int {
+	return len(h.entries)
+}
+
+func (h *fileHistory) At(idx int) string {
+	return h.entries[len(h.entries)-1-idx]
+}
+
+func (h *fileHistory) Close() error {
+	return h.file.Close()
+}
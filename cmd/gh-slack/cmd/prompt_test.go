@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileHistory(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	path, err := historyPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+	for i := 0; i < maxHistory+10; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	err = os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := openHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if history.Len() != maxHistory {
+		t.Fatalf("expected the history to be trimmed to %d entries, got %d", maxHistory, history.Len())
+	}
+	if history.At(0) != "line 509" || history.At(maxHistory-1) != "line 10" {
+		t.Errorf("expected the most recent entries to be kept, newest first, got %q to %q", history.At(0), history.At(maxHistory-1))
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(content), "\n"); n != maxHistory {
+		t.Errorf("expected the file to be trimmed to %d lines, got %d", maxHistory, n)
+	}
+
+	history.Add("hello")
+	if history.Len() != maxHistory {
+		t.Errorf("expected the history to stay at %d entries, got %d", maxHistory, history.Len())
+	}
+	if history.At(0) != "hello" || history.At(maxHistory-1) != "line 11" {
+		t.Errorf("expected the oldest entry to be dropped, got %q to %q", history.At(0), history.At(maxHistory-1))
+	}
+
+	err = history.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	history, err = openHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer history.Close()
+
+	if history.At(0) != "hello" {
+		t.Errorf("expected the added entry to be recalled in a later session, got %q", history.At(0))
+	}
+}
+
+func TestPromptWithoutTerminal(t *testing.T) {
+	stateHome := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", stateHome)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	stdin := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() { os.Stdin = stdin })
+
+	_, err = w.WriteString("hello\n/quit\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	p, err := newPrompt("#ops> ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	for _, expected := range []string{"hello", "/quit"} {
+		line, err := p.ReadLine()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line != expected {
+			t.Errorf("expected %q, got %q", expected, line)
+		}
+	}
+
+	_, err = p.ReadLine()
+	if !errors.Is(err, io.EOF) {
+		t.Errorf("expected EOF at the end of the input, got %v", err)
+	}
+
+	entries, err := os.ReadDir(stateHome)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Error("expected no history to be kept without a terminal")
+	}
+}
@@ -3,13 +3,13 @@ package cmd
 import (
 	"errors"
 	"fmt"
-	"io"
-	"log"
 	"net/url"
 	"os"
 	"regexp"
 	"strings"
+	"sync"
 
+	"github.com/cli/go-gh/v2/pkg/config"
 	"github.com/rneatherway/gh-slack/internal/gh"
 	"github.com/rneatherway/gh-slack/internal/markdown"
 	"github.com/rneatherway/gh-slack/internal/slackclient"
@@ -18,14 +18,18 @@ import (
 )
 
 var readCmd = &cobra.Command{
-	Use:   "read [flags] <START>",
+	Use:   "read [flags] <START>...",
 	Short: "Reads a Slack channel and outputs the messages as markdown",
 	Long:  `Reads a Slack channel and outputs the messages as markdown for GitHub issues.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return readSlack(args)
 	},
 	Example: `  gh-slack read <slack-permalink>
-  gh-slack read --details --issue <issue-url> <slack-permalink>`,
+  gh-slack read -t <team-name> -c <channel-name> 1648028606.962719
+  gh-slack read --details --issue <issue-url> <slack-permalink>
+  gh-slack read --merge --details <slack-permalink> <slack-permalink>
+  gh-slack read --offline <slack-permalink>
+  gh-slack read --github-org <org> --mention --issue <repo-url> <slack-permalink>`,
 }
 
 var (
@@ -33,59 +37,282 @@ var (
 	issueRE = regexp.MustCompile("^/[^/]+/[^/]+/(issues|pull)/[0-9]+/?$")
 )
 
+// teamIdentity identifies the team (workspace, or Enterprise Grid
+// organization) of a permalink, by its domain, its ID or both.
+type teamIdentity struct {
+	// domain is the subdomain of slack.com, e.g. "acme" or "acme.enterprise",
+	// or a GovSlack host such as "agency.slack-gov.com".
+	domain string
+	// id is the team ID, e.g. "T0123ABCD".
+	id string
+}
+
+func (t teamIdentity) String() string {
+	if t.domain != "" {
+		return t.domain
+	}
+	return t.id
+}
+
 type linkParts struct {
-	team      string
+	team      teamIdentity
 	channelID string
 	timestamp string
 	thread    string
 }
 
+// parsePermalink accepts the forms of link to a message that Slack produces:
+//
+//	https://<team>.slack.com/archives/<channel>/p<ts>[?thread_ts=<ts>&cid=<channel>]
+//	https://<org>.enterprise.slack.com/archives/<channel>/p<ts>
+//	https://app.slack.com/client/<team ID>/<channel>/p<ts>
+//	https://app.slack.com/client/<team ID>/<channel>/thread/<channel>-<ts>
+//	https://slack.com/app_redirect?team=<team ID>&channel=<channel>&message_ts=<ts>
+//
+// GovSlack links are the same, but on slack-gov.com.
 func parsePermalink(link string) (linkParts, error) {
 	u, err := url.Parse(link)
 	if err != nil {
 		return linkParts{}, err
 	}
 
-	team, ok := strings.CutSuffix(u.Hostname(), ".slack.com")
-	if !ok {
-		return linkParts{}, fmt.Errorf("expected slack.com subdomain: %q", link)
+	host := strings.ToLower(u.Hostname())
+	var domain string
+	var gov bool
+	if d, ok := strings.CutSuffix(host, ".slack.com"); ok {
+		domain = d
+	} else if d, ok := strings.CutSuffix(host, slackclient.GovSlackSuffix); ok {
+		domain, gov = d, true
+	} else if host == "slack.com" || host == strings.TrimPrefix(slackclient.GovSlackSuffix, ".") {
+		gov = host != "slack.com"
+	} else {
+		return linkParts{}, fmt.Errorf("expected a slack.com or slack-gov.com link: %q", link)
+	}
+
+	var parts linkParts
+	pathSegments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	case len(pathSegments) == 3 && pathSegments[0] == "archives" && domain != "app":
+		parts = linkParts{
+			team:      teamIdentity{domain: domain, id: u.Query().Get("team")},
+			channelID: pathSegments[1],
+			thread:    u.Query().Get("thread_ts"),
+		}
+		parts.timestamp, err = parsePermalinkTimestamp(pathSegments[2])
+	case len(pathSegments) >= 3 && pathSegments[0] == "client" && domain == "app":
+		parts = linkParts{
+			team:      teamIdentity{id: pathSegments[1]},
+			channelID: pathSegments[2],
+			thread:    u.Query().Get("thread_ts"),
+		}
+		switch rest := pathSegments[3:]; {
+		case len(rest) == 1 && strings.HasPrefix(rest[0], "p"):
+			parts.timestamp, err = parsePermalinkTimestamp(rest[0])
+		case len(rest) == 2 && rest[0] == "thread":
+			channel, ts, ok := strings.Cut(rest[1], "-")
+			if !ok || channel != parts.channelID {
+				return linkParts{}, fmt.Errorf("expected thread of the form <channel>-<timestamp>: %q", link)
+			}
+			parts.timestamp, parts.thread = ts, ts
+		default:
+			return linkParts{}, fmt.Errorf("expected a link to a message, not a channel: %q", link)
+		}
+	case len(pathSegments) == 1 && pathSegments[0] == "app_redirect" && (domain == "" || domain == "app"):
+		query := u.Query()
+		parts = linkParts{
+			team:      teamIdentity{id: query.Get("team")},
+			channelID: query.Get("channel"),
+			timestamp: query.Get("message_ts"),
+			thread:    query.Get("thread_ts"),
+		}
+		if parts.team.id == "" || parts.channelID == "" || parts.timestamp == "" {
+			return linkParts{}, fmt.Errorf("expected team, channel and message_ts parameters: %q", link)
+		}
+	default:
+		return linkParts{}, fmt.Errorf("expected path of the form /archives/<channel>/p<timestamp> or /client/<team>/<channel>/p<timestamp>: %q", link)
+	}
+
+	if err != nil {
+		return linkParts{}, err
+	}
+
+	if parts.channelID == "" {
+		return linkParts{}, fmt.Errorf("expected a channel: %q", link)
+	}
+
+	parts.timestamp, err = parseTimestamp(parts.timestamp)
+	if err != nil {
+		return linkParts{}, err
+	}
+
+	parts.thread, err = parseOptionalTimestamp(parts.thread)
+	if err != nil {
+		return linkParts{}, fmt.Errorf("invalid thread: %w", err)
+	}
+
+	if parts.team.domain == "" && parts.team.id == "" {
+		return linkParts{}, fmt.Errorf("expected a team subdomain or team parameter: %q", link)
+	}
+
+	if gov && parts.team.domain != "" {
+		parts.team.domain += slackclient.GovSlackSuffix
+	}
+
+	return parts, nil
+}
+
+// timestampError describes the expected form of a timestamp.
+func timestampError(ts, expected string) error {
+	return fmt.Errorf("invalid message timestamp %q, expected %s", ts, expected)
+}
+
+// parseTimestamp checks that ts is a message timestamp, seconds and
+// microseconds since the epoch, e.g. 1648028606.962719.
+func parseTimestamp(ts string) (string, error) {
+	if !slackclient.ValidTimestamp(ts) {
+		return "", timestampError(ts, "<seconds>.<microseconds>, e.g. 1648028606.962719")
+	}
+	return ts, nil
+}
+
+// parseOptionalTimestamp is like parseTimestamp, but accepts "".
+func parseOptionalTimestamp(ts string) (string, error) {
+	if ts == "" {
+		return "", nil
+	}
+	return parseTimestamp(ts)
+}
+
+// parsePermalinkTimestamp converts the last segment of a permalink, e.g.
+// p1648028606962719, to a message timestamp.
+func parsePermalinkTimestamp(segment string) (string, error) {
+	digits, ok := strings.CutPrefix(segment, "p")
+	if ok && len(digits) > 6 {
+		ts := digits[:len(digits)-6] + "." + digits[len(digits)-6:]
+		if slackclient.ValidTimestamp(ts) {
+			return ts, nil
+		}
+	}
+	return "", timestampError(segment, "p followed by the seconds and microseconds, e.g. p1648028606962719")
+}
+
+// channelIDRE matches the IDs of channels, private channels and direct
+// messages, which can be used in place of a channel name.
+var channelIDRE = regexp.MustCompile("^[CGD][A-Z0-9]{8,}$")
+
+// timestampParts returns the parts of a link to the message with the given
+// timestamp, using the team and channel from flags or the configuration. If
+// the channel is given by name, it is returned separately to be looked up.
+func timestampParts(cfg *config.Config, ts string) (linkParts, string, error) {
+	var parts linkParts
+	var err error
+	parts.timestamp, err = parseTimestamp(ts)
+	if err != nil {
+		return linkParts{}, "", fmt.Errorf("<START> is neither a permalink nor a timestamp: %w", err)
 	}
 
-	pathSegments := strings.Split(strings.TrimPrefix(u.Path, "/"), "/")
-	if len(pathSegments) != 3 || pathSegments[0] != "archives" {
-		return linkParts{}, fmt.Errorf("expected path of the form /archives/<channel>/p<timestamp>: %q", link)
+	parts.thread, err = parseOptionalTimestamp(opts.Thread)
+	if err != nil {
+		return linkParts{}, "", fmt.Errorf("invalid --thread: %w", err)
 	}
 
-	channel := pathSegments[1]
-	timestamp := pathSegments[2][1:len(pathSegments[2])-6] + "." + pathSegments[2][len(pathSegments[2])-6:]
+	parts.team.domain = opts.Team
+	if parts.team.domain == "" {
+		parts.team.domain, err = getGHSlackConfigValue(cfg, "team")
+		if err != nil {
+			return linkParts{}, "", err
+		}
+	}
+
+	channel := opts.Channel
+	if channel == "" {
+		channel, err = getGHSlackConfigValue(cfg, "channel")
+		if err != nil {
+			return linkParts{}, "", err
+		}
+	}
 
-	return linkParts{
-		team:      team,
-		channelID: channel,
-		timestamp: timestamp,
-		thread:    u.Query().Get("thread_ts"),
-	}, nil
+	if channelIDRE.MatchString(channel) {
+		parts.channelID = channel
+		return parts, "", nil
+	}
+	return parts, strings.TrimPrefix(channel, "#"), nil
+}
+
+// resolveTeam returns the name of the team for the client. Links that only
+// identify the team by ID are resolved using the team_id of a profile, or of
+// the configuration as a whole.
+func resolveTeam(cfg *config.Config, team teamIdentity) (string, error) {
+	if team.domain != "" {
+		return team.domain, nil
+	}
+
+	name, err := profileForTeam(cfg, team)
+	if err != nil {
+		return "", err
+	}
+	if name != "" {
+		return cfg.Get(profileKey(name, "team"))
+	}
+
+	id, err := getOptionalGHSlackConfigValue(cfg, "team_id")
+	if err != nil {
+		return "", err
+	}
+	if strings.EqualFold(id, team.id) {
+		return getGHSlackConfigValue(cfg, "team")
+	}
+
+	return "", fmt.Errorf("the link only identifies the team by its ID, %s; add team_id: %s to the profile for the team in the configuration", team.id, team.id)
 }
 
 var opts struct {
 	Args struct {
-		Start string
+		Start []string
 	}
-	Limit   int
-	Version bool
-	Details bool
-	Issue   string
+	Team        string
+	Channel     string
+	Thread      string
+	Limit       int
+	Version     bool
+	Details     bool
+	Issue       string
+	Offline     bool
+	NameStyle   string
+	GitHubUsers string
+	GitHubOrg   string
+	Mention     bool
+	Merge       bool
 }
 
 func init() {
+	readCmd.Flags().StringVarP(&opts.Team, "team", "t", "", "Slack team name, when <START> is a timestamp (or team in config)")
+	readCmd.Flags().StringVarP(&opts.Channel, "channel", "c", "", "Channel name or ID, when <START> is a timestamp (or channel in config)")
+	readCmd.Flags().StringVar(&opts.Thread, "thread", "", "Timestamp of the thread's first message, when <START> is a reply in a thread")
 	readCmd.Flags().IntVarP(&opts.Limit, "limit", "l", 20, "Number of _channel_ messages to be fetched after the starting message (all thread messages are fetched)")
 	readCmd.Flags().BoolVar(&opts.Version, "version", false, "Output version information")
 	readCmd.Flags().BoolVarP(&opts.Details, "details", "d", false, "Wrap the markdown output in HTML <details> tags")
 	readCmd.Flags().StringVarP(&opts.Issue, "issue", "i", "", "The URL of a repository to post the output as a new issue, or the URL of an issue (or pull request) to add a comment to")
+	readCmd.Flags().StringVar(&opts.NameStyle, "name-style", "", "How to name users: handle, display, real or both (default handle, or name_style in config)")
+	readCmd.Flags().StringVar(&opts.GitHubUsers, "github-users", "", "YAML file mapping Slack users (ID, handle or email) to GitHub logins")
+	readCmd.Flags().StringVar(&opts.GitHubOrg, "github-org", "", "Map Slack users to members of this GitHub organization with the same email")
+	readCmd.Flags().BoolVar(&opts.Mention, "mention", false, "@-mention the GitHub users that Slack users are mapped to")
+	readCmd.Flags().BoolVar(&opts.Merge, "merge", false, "With several <START>s, merge their messages in time order, labelled by conversation, rather than in one section each")
+	readCmd.Flags().BoolVar(&opts.Offline, "offline", false, "Only use messages previously stored in the local archive, without contacting Slack")
 	readCmd.SetHelpTemplate(readCmdUsage)
 	readCmd.SetUsageTemplate(readCmdUsage)
 }
 
+// readTarget is one <START> given to read, the client for its team, and the
+// conversation read from it.
+type readTarget struct {
+	start        string
+	parts        linkParts
+	channelName  string
+	client       *slackclient.SlackClient
+	conversation markdown.Conversation
+}
+
 func readSlack(args []string) error {
 	if opts.Version {
 		fmt.Printf("gh-slack %s (%s)\n", version.Version(), version.Commit())
@@ -95,9 +322,11 @@ func readSlack(args []string) error {
 	if len(args) == 0 {
 		return errors.New("the required argument <START> was not provided")
 	}
-	opts.Args.Start = args[0]
-	if opts.Args.Start == "" {
-		return errors.New("the required argument <START> was not provided")
+	opts.Args.Start = args
+	for _, start := range opts.Args.Start {
+		if start == "" {
+			return errors.New("the required argument <START> was not provided")
+		}
 	}
 
 	var repoUrl, issueOrPrUrl, subCmd string
@@ -121,52 +350,112 @@ func readSlack(args []string) error {
 		}
 	}
 
-	linkParts, err := parsePermalink(opts.Args.Start)
+	cfg, err := config.Read(nil)
 	if err != nil {
 		return err
 	}
 
-	logger := log.New(io.Discard, "", log.LstdFlags)
-	if verbose {
-		logger = log.Default()
-	}
-
-	client, err := slackclient.New(linkParts.team, logger)
+	targets, err := readTargets(cfg, opts.Args.Start)
 	if err != nil {
 		return err
 	}
 
-	history, err := client.History(linkParts.channelID, linkParts.timestamp, linkParts.thread, opts.Limit)
+	// The permalink's team is a better guide than the repository's profile,
+	// as long as every permalink is from the same team.
+	if !profileChosen() && sameTeam(targets) {
+		teamProfile, err := profileForTeam(cfg, targets[0].parts.team)
+		if err != nil {
+			return err
+		}
+		if teamProfile != "" {
+			profile = teamProfile
+		}
+	}
+
+	var style slackclient.NameStyle
+	if opts.NameStyle != "" {
+		style, err = slackclient.ParseNameStyle(opts.NameStyle)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Permalinks from the same team share a client, and so its caches.
+	clients := map[string]*slackclient.SlackClient{}
+	defer func() {
+		for _, client := range clients {
+			client.Close()
+		}
+	}()
+	for i := range targets {
+		team, err := resolveTeam(cfg, targets[i].parts.team)
+		if err != nil {
+			return err
+		}
+
+		client, ok := clients[team]
+		if !ok {
+			client, err = readClient(cfg, team)
+			if err != nil {
+				return err
+			}
+			if opts.NameStyle != "" {
+				client.WithNameStyle(style)
+			}
+			clients[team] = client
+		}
+		targets[i].client = client
+	}
+
+	// The conversation names are only needed to title the output.
+	needNames := len(targets) > 1 || opts.Details || repoUrl != ""
+	err = readConversations(targets, needNames)
 	if err != nil {
 		return err
 	}
 
-	output, err := markdown.FromMessages(client, history)
+	githubUsers, err := loadGitHubUsers(cfg, opts.GitHubUsers, opts.GitHubOrg, opts.Offline)
 	if err != nil {
 		return err
 	}
 
-	var channelName string
-	if opts.Details {
-		channelInfo, err := client.ChannelInfo(linkParts.channelID)
+	markdownOpts := markdown.Options{
+		GitHubUsers: githubUsers,
+		Mention:     opts.Mention,
+	}
+
+	conversations := make([]markdown.Conversation, len(targets))
+	names := make([]string, len(targets))
+	for i, target := range targets {
+		conversations[i] = target.conversation
+		names[i] = target.conversation.Name
+	}
+	conversationName := strings.Join(names, ", ")
+
+	var output string
+	if len(conversations) == 1 {
+		c := conversations[0]
+		output, err = markdown.FromMessagesWithOptions(c.Client, c.History, markdownOpts)
 		if err != nil {
 			return err
 		}
 
-		channelName = channelInfo.Name
-		output = markdown.WrapInDetails(channelName, opts.Args.Start, output)
-	}
+		if opts.Details {
+			output = markdown.WrapInDetails(c.Name, c.Link, output)
+		}
+	} else {
+		output, err = markdown.FromConversations(conversations, opts.Merge, markdownOpts)
+		if err != nil {
+			return err
+		}
 
-	if repoUrl != "" {
-		if channelName == "" {
-			channelInfo, err := client.ChannelInfo(linkParts.channelID)
-			if err != nil {
-				return err
-			}
-			channelName = channelInfo.Name
+		if opts.Details {
+			output = markdown.WrapConversationsInDetails(conversations, output)
 		}
+	}
 
-		err := gh.NewIssue(repoUrl, channelName, output)
+	if repoUrl != "" {
+		err := gh.NewIssue(repoUrl, conversationName, output)
 		if err != nil {
 			return err
 		}
@@ -182,11 +471,129 @@ func readSlack(args []string) error {
 	return nil
 }
 
+// readTargets parses each <START>. A timestamp may be given instead of a
+// permalink, with the channel and team from flags or the configuration.
+func readTargets(cfg *config.Config, starts []string) ([]readTarget, error) {
+	targets := make([]readTarget, len(starts))
+	for i, start := range starts {
+		var err error
+		targets[i].start = start
+		if strings.Contains(start, "://") {
+			targets[i].parts, err = parsePermalink(start)
+		} else {
+			targets[i].parts, targets[i].channelName, err = timestampParts(cfg, start)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return targets, nil
+}
+
+func sameTeam(targets []readTarget) bool {
+	for _, target := range targets[1:] {
+		if target.parts.team != targets[0].parts.team {
+			return false
+		}
+	}
+	return true
+}
+
+// readConversations fetches the history of each target concurrently, and
+// returns the first error in the order the targets were given.
+func readConversations(targets []readTarget, needNames bool) error {
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i := range targets {
+		wg.Add(1)
+		go func(target *readTarget) {
+			defer wg.Done()
+			errs[i] = readConversation(target, needNames)
+		}(&targets[i])
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			if len(targets) > 1 {
+				return fmt.Errorf("failed to read %s: %w", targets[i].start, err)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readConversation(target *readTarget, needNames bool) error {
+	client := target.client
+	parts := &target.parts
+
+	if target.channelName != "" {
+		var err error
+		parts.channelID, err = client.ChannelIDForName(target.channelName)
+		if err != nil {
+			return err
+		}
+	}
+
+	history, err := client.History(parts.channelID, parts.timestamp, parts.thread, opts.Limit)
+	if err != nil {
+		return err
+	}
+
+	target.conversation = markdown.Conversation{
+		Client:  client,
+		Link:    target.start,
+		History: history,
+	}
+
+	if needNames {
+		target.conversation.Name, err = client.ConversationName(parts.channelID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readClient(cfg *config.Config, team string) (*slackclient.SlackClient, error) {
+	if opts.Offline {
+		client, err := newOfflineSlackClient(cfg, team)
+		if err != nil {
+			return nil, err
+		}
+
+		client.UseArchive()
+		return client, nil
+	}
+
+	client, err := newSlackClient(cfg, team)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := getOptionalGHSlackConfigValue(cfg, "archive")
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	if archive == "true" {
+		client.UseArchive()
+	}
+
+	return client, nil
+}
+
 const readCmdUsage string = `Usage:{{if .Runnable}}
   {{.UseLine}}{{end}}{{if .HasAvailableSubCommands}}
   {{.CommandPath}} [command] <START>{{end}}
 
-  where <START> is a required argument which should be permalink for the first message to fetch. Following messages are then fetched from that channel (or thread if applicable).{{if gt (len .Aliases) 0}}
+  where <START> is a required argument which should be permalink for the first message to fetch. Following messages are then fetched from that channel (or thread if applicable).
+  <START> can also be the timestamp of the message, e.g. 1648028606.962719, with the channel and team given by flags or the configuration.{{if gt (len .Aliases) 0}}
 Aliases:
   {{.NameAndAliases}}{{end}}{{if .HasExample}}
 
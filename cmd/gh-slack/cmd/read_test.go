@@ -1,6 +1,11 @@
 package cmd
 
-import "testing"
+import (
+	"strings"
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/config"
+)
 
 func TestParsePermalink(t *testing.T) {
 	tests := []struct {
@@ -10,7 +15,7 @@ func TestParsePermalink(t *testing.T) {
 		{
 			link: "https://github.slack.com/archives/CP9GMKJCE/p1648028606962719",
 			expected: linkParts{
-				team:      "github",
+				team:      teamIdentity{domain: "github"},
 				channelID: "CP9GMKJCE",
 				timestamp: "1648028606.962719",
 			},
@@ -18,7 +23,7 @@ func TestParsePermalink(t *testing.T) {
 		{
 			link: "https://sanity-io-land.slack.com/archives/C9Y51FDGA/p1709663536325529",
 			expected: linkParts{
-				team:      "sanity-io-land",
+				team:      teamIdentity{domain: "sanity-io-land"},
 				channelID: "C9Y51FDGA",
 				timestamp: "1709663536.325529",
 			},
@@ -26,12 +31,77 @@ func TestParsePermalink(t *testing.T) {
 		{
 			link: "https://example.slack.com/archives/ABC123/p1709663536325529?thread_ts=1234567890.123456&cid=ABC123",
 			expected: linkParts{
-				team:      "example",
+				team:      teamIdentity{domain: "example"},
 				channelID: "ABC123",
 				thread:    "1234567890.123456",
 				timestamp: "1709663536.325529",
 			},
 		},
+		{
+			link: "https://acme.enterprise.slack.com/archives/C0123/p1709663536325529",
+			expected: linkParts{
+				team:      teamIdentity{domain: "acme.enterprise"},
+				channelID: "C0123",
+				timestamp: "1709663536.325529",
+			},
+		},
+		{
+			link: "https://Example.Slack.com/archives/C0123/p1709663536325529/",
+			expected: linkParts{
+				team:      teamIdentity{domain: "example"},
+				channelID: "C0123",
+				timestamp: "1709663536.325529",
+			},
+		},
+		{
+			link: "https://agency.slack-gov.com/archives/C0123/p1709663536325529",
+			expected: linkParts{
+				team:      teamIdentity{domain: "agency.slack-gov.com"},
+				channelID: "C0123",
+				timestamp: "1709663536.325529",
+			},
+		},
+		{
+			link: "https://app.slack.com/client/T0123ABCD/C0123/p1709663536325529",
+			expected: linkParts{
+				team:      teamIdentity{id: "T0123ABCD"},
+				channelID: "C0123",
+				timestamp: "1709663536.325529",
+			},
+		},
+		{
+			link: "https://app.slack.com/client/T0123ABCD/C0123/thread/C0123-1709663536.325529",
+			expected: linkParts{
+				team:      teamIdentity{id: "T0123ABCD"},
+				channelID: "C0123",
+				timestamp: "1709663536.325529",
+				thread:    "1709663536.325529",
+			},
+		},
+		{
+			link: "https://app.slack-gov.com/client/T0123ABCD/C0123/p1709663536325529",
+			expected: linkParts{
+				team:      teamIdentity{id: "T0123ABCD"},
+				channelID: "C0123",
+				timestamp: "1709663536.325529",
+			},
+		},
+		{
+			link: "https://slack.com/archives/C0123/p1709663536325529?team=T0123ABCD",
+			expected: linkParts{
+				team:      teamIdentity{id: "T0123ABCD"},
+				channelID: "C0123",
+				timestamp: "1709663536.325529",
+			},
+		},
+		{
+			link: "https://slack.com/app_redirect?team=T0123ABCD&channel=C0123&message_ts=1709663536.325529",
+			expected: linkParts{
+				team:      teamIdentity{id: "T0123ABCD"},
+				channelID: "C0123",
+				timestamp: "1709663536.325529",
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -40,8 +110,210 @@ func TestParsePermalink(t *testing.T) {
 			t.Errorf("unexpected error: %v", err)
 		}
 
-		if actual.team != test.expected.team || actual.channelID != test.expected.channelID || actual.timestamp != test.expected.timestamp {
+		if actual != test.expected {
 			t.Errorf("unexpected result for link %s, got %+v, want %+v", test.link, actual, test.expected)
 		}
 	}
 }
+
+func TestParsePermalinkErrors(t *testing.T) {
+	for _, link := range []string{
+		"https://example.com/archives/C0123/p1709663536325529",
+		"https://slack.com.example.com/archives/C0123/p1709663536325529",
+		"https://example.slack.com/messages/C0123",
+		"https://app.slack.com/client/T0123ABCD/C0123",
+		"https://app.slack.com/client/T0123ABCD/C0123/thread/C0456-1709663536.325529",
+		"https://slack.com/archives/C0123/p1709663536325529",
+		"https://slack.com/app_redirect?channel=C0123",
+		"https://example.slack.com/archives//p1709663536325529",
+		"https://example.slack.com/archives/C0123/p123",
+		"https://example.slack.com/archives/C0123/p1709663536325529?thread_ts=123",
+	} {
+		_, err := parsePermalink(link)
+		if err == nil {
+			t.Errorf("expected an error for %s", link)
+		}
+	}
+}
+
+func TestResolveTeam(t *testing.T) {
+	cfg := config.ReadFromString(profilesConfig)
+
+	for _, test := range []struct {
+		team     teamIdentity
+		expected string
+	}{
+		{teamIdentity{domain: "example"}, "example"},
+		{teamIdentity{id: "T0123"}, "Acme"},
+	} {
+		actual, err := resolveTeam(cfg, test.team)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != test.expected {
+			t.Errorf("expected %s to resolve to %q, got %q", test.team, test.expected, actual)
+		}
+	}
+
+	_, err := resolveTeam(cfg, teamIdentity{id: "T9999"})
+	if err == nil {
+		t.Error("expected an error for an unknown team ID")
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	for _, test := range []struct {
+		ts, expected string
+	}{
+		{"1648028606.962719", "1648028606.962719"},
+		{"0.000000", "0.000000"},
+		{"1648028606", ""},
+		{"1648028606.96271", ""},
+		{"1648028606.9627190", ""},
+		{".962719", ""},
+		{"-1.962719", ""},
+		{"1648028606.96271x", ""},
+		{"", ""},
+	} {
+		actual, err := parseTimestamp(test.ts)
+		if test.expected == "" && err == nil {
+			t.Errorf("expected an error for %q, got %q", test.ts, actual)
+		} else if test.expected != "" && actual != test.expected {
+			t.Errorf("expected %q for %q, got %q (%v)", test.expected, test.ts, actual, err)
+		}
+	}
+}
+
+func TestParsePermalinkTimestamp(t *testing.T) {
+	for _, test := range []struct {
+		segment, expected string
+	}{
+		{"p1648028606962719", "1648028606.962719"},
+		{"p1962719", "1.962719"},
+		{"p962719", ""},
+		{"p", ""},
+		{"", ""},
+		{"1648028606962719", ""},
+		{"p16480286069627x9", ""},
+		{"p1648028606.962719", ""},
+	} {
+		actual, err := parsePermalinkTimestamp(test.segment)
+		if test.expected == "" && err == nil {
+			t.Errorf("expected an error for %q, got %q", test.segment, actual)
+		} else if test.expected != "" && actual != test.expected {
+			t.Errorf("expected %q for %q, got %q (%v)", test.expected, test.segment, actual, err)
+		}
+	}
+}
+
+func TestTimestampParts(t *testing.T) {
+	cfg := config.ReadFromString(profilesConfig)
+	t.Cleanup(func() { opts.Team, opts.Channel, opts.Thread = "", "", "" })
+
+	parts, channelName, err := timestampParts(cfg, "1648028606.962719")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := linkParts{team: teamIdentity{domain: "home"}, timestamp: "1648028606.962719"}
+	if parts != expected || channelName != "general" {
+		t.Errorf("unexpected result %+v in %q", parts, channelName)
+	}
+
+	opts.Team, opts.Channel, opts.Thread = "acme", "C0123ABCD", "1648028600.000001"
+	parts, channelName, err = timestampParts(cfg, "1648028606.962719")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = linkParts{team: teamIdentity{domain: "acme"}, channelID: "C0123ABCD", timestamp: "1648028606.962719", thread: "1648028600.000001"}
+	if parts != expected || channelName != "" {
+		t.Errorf("unexpected result %+v in %q", parts, channelName)
+	}
+
+	_, _, err = timestampParts(cfg, "p1648028606962719")
+	if err == nil {
+		t.Error("expected an error for an invalid timestamp")
+	}
+}
+
+func TestReadTargets(t *testing.T) {
+	cfg := config.ReadFromString(profilesConfig)
+
+	targets, err := readTargets(cfg, []string{
+		"https://acme.slack.com/archives/C0123ABCD/p1648028606962719",
+		"1648028700.000001",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(targets) != 2 {
+		t.Fatalf("expected 2 targets, got %d", len(targets))
+	}
+	if targets[0].parts.channelID != "C0123ABCD" || targets[1].channelName != "general" {
+		t.Errorf("unexpected targets %+v", targets)
+	}
+	if sameTeam(targets) {
+		t.Error("expected the targets to be from different teams")
+	}
+	if !sameTeam(targets[:1]) {
+		t.Error("expected a single target to be from the same team")
+	}
+
+	_, err = readTargets(cfg, []string{
+		"https://acme.slack.com/archives/C0123ABCD/p1648028606962719",
+		"https://acme.slack.com/archives/C0123ABCD/p123",
+	})
+	if err == nil {
+		t.Error("expected an error for an invalid permalink")
+	}
+}
+
+func FuzzParseTimestamp(f *testing.F) {
+	for _, seed := range []string{"1648028606.962719", "1.000000", "", ".", "1.", "p1648028606962719"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, ts string) {
+		actual, err := parseTimestamp(ts)
+		if err == nil && actual != ts {
+			t.Errorf("expected %q to be returned unchanged, got %q", ts, actual)
+		}
+
+		segment := "p" + strings.Replace(ts, ".", "", 1)
+		fromPermalink, err := parsePermalinkTimestamp(segment)
+		if err == nil {
+			_, err = parseTimestamp(fromPermalink)
+			if err != nil {
+				t.Errorf("%q parsed to an invalid timestamp: %v", segment, err)
+			}
+		}
+	})
+}
+
+func FuzzParsePermalink(f *testing.F) {
+	for _, seed := range []string{
+		"https://github.slack.com/archives/CP9GMKJCE/p1648028606962719",
+		"https://example.slack.com/archives/ABC123/p1709663536325529?thread_ts=1234567890.123456&cid=ABC123",
+		"https://app.slack.com/client/T0123ABCD/C0123/thread/C0123-1709663536.325529",
+		"https://slack.com/app_redirect?team=T0123ABCD&channel=C0123&message_ts=1709663536.325529",
+		"https://example.slack.com/archives/C0123/p",
+		"https://example.slack.com/archives/C0123/",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, link string) {
+		parts, err := parsePermalink(link)
+		if err != nil {
+			return
+		}
+
+		_, err = parseTimestamp(parts.timestamp)
+		if err != nil {
+			t.Errorf("%q parsed to an invalid timestamp: %v", link, err)
+		}
+		if parts.channelID == "" {
+			t.Errorf("%q parsed without a channel", link)
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cli/go-gh/v2/pkg/config"
+	"gopkg.in/yaml.v3"
+)
+
+// repoConfigFile is the configuration file for the repository containing the
+// working directory, relative to the root of the repository.
+var repoConfigFile = filepath.Join(".github", "gh-slack.yml")
+
+// repoConfig holds the keys from repoConfigFile, if there is one. They take
+// precedence over gh's configuration, but not over flags or a profile chosen
+// with --profile or GH_SLACK_PROFILE.
+var repoConfig *config.Config
+
+// repoConfigPath is the path repoConfig was read from.
+var repoConfigPath string
+
+// globalOnlyKeys can only be set in gh's configuration, so that a
+// repository can't choose which credentials are used or where they are sent.
+var globalOnlyKeys = map[string]bool{
+	"auth":       true,
+	"token_file": true,
+}
+
+// findRepoConfig returns the path of repoConfigFile in the git repository
+// containing dir, or "" if there is none.
+func findRepoConfig(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		_, err := os.Stat(filepath.Join(dir, ".git"))
+		if err == nil {
+			file := filepath.Join(dir, repoConfigFile)
+			_, err = os.Stat(file)
+			if errors.Is(err, os.ErrNotExist) {
+				return "", nil
+			}
+			return file, err
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// loadRepoConfig reads the configuration of the repository containing dir.
+func loadRepoConfig(dir string) error {
+	path, err := findRepoConfig(dir)
+	if err != nil || path == "" {
+		return err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	// ReadFromString ignores invalid YAML, so check it first.
+	var keys map[string]any
+	err = yaml.Unmarshal(content, &keys)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	// Refusing to run would stop even "auth login" from working in the
+	// repository, so these keys are ignored by getRepoConfigValue instead.
+	for key := range keys {
+		if globalOnlyKeys[key] {
+			fmt.Fprintf(os.Stderr, "Ignoring %q in %s, as it is only read from gh's configuration\n", key, path)
+		}
+	}
+
+	repoConfig = config.ReadFromString(string(content))
+	repoConfigPath = path
+	return nil
+}
+
+// getRepoConfigValue returns the value of the key in the repository's
+// configuration, or false if it is not set there.
+func getRepoConfigValue(key string) (string, bool, error) {
+	if repoConfig == nil || globalOnlyKeys[key] {
+		return "", false, nil
+	}
+
+	s, err := repoConfig.Get([]string{key})
+	var notFound *config.KeyNotFoundError
+	if errors.As(err, &notFound) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, fmt.Errorf("failed to read %q from %s: %w", key, repoConfigPath, err)
+	}
+
+	return s, true, nil
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/config"
+)
+
+// writeRepoConfig creates a repository with the given configuration and
+// returns a directory inside it.
+func writeRepoConfig(t *testing.T, content string) string {
+	repo := t.TempDir()
+	for _, dir := range []string{".git", ".github", filepath.Join("src", "app")} {
+		err := os.MkdirAll(filepath.Join(repo, dir), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := os.WriteFile(filepath.Join(repo, repoConfigFile), []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { repoConfig, repoConfigPath = nil, "" })
+	return filepath.Join(repo, "src", "app")
+}
+
+func TestRepoConfigIsLayeredOverGlobalConfig(t *testing.T) {
+	dir := writeRepoConfig(t, "channel: deploys\nbot: deploy-bot\n")
+	err := loadRepoConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.ReadFromString(profilesConfig)
+	selectProfile(t, "work")
+
+	for key, expected := range map[string]string{"channel": "deploys", "bot": "deploy-bot", "team": "Acme"} {
+		actual, err := getGHSlackConfigValue(cfg, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Errorf("expected %s to be %q, got %q", key, expected, actual)
+		}
+	}
+}
+
+func TestChosenProfileIsLayeredOverRepoConfig(t *testing.T) {
+	dir := writeRepoConfig(t, "channel: deploys\nbot: deploy-bot\n")
+	err := loadRepoConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.ReadFromString(profilesConfig)
+	selectProfile(t, "work")
+	t.Setenv("GH_SLACK_PROFILE", "work")
+
+	for key, expected := range map[string]string{"channel": "ops", "bot": "deploy-bot", "team": "Acme"} {
+		actual, err := getGHSlackConfigValue(cfg, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Errorf("expected %s to be %q, got %q", key, expected, actual)
+		}
+	}
+}
+
+func TestRepoConfigCannotChooseCredentials(t *testing.T) {
+	dir := writeRepoConfig(t, "channel: deploys\ntoken_file: /etc/passwd\n")
+	err := loadRepoConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, ok, err := getRepoConfigValue("token_file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Error("expected token_file in the repository's configuration to be ignored")
+	}
+
+	channel, _, err := getRepoConfigValue("channel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if channel != "deploys" {
+		t.Errorf("expected the other keys to be read, got channel %q", channel)
+	}
+}
+
+func TestNoRepoConfig(t *testing.T) {
+	err := loadRepoConfig(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repoConfig != nil {
+		t.Error("expected no repository configuration")
+	}
+}
@@ -1,12 +1,17 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/cli/go-gh/v2/pkg/config"
+	"github.com/rneatherway/gh-slack/internal/slackclient"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -25,27 +30,227 @@ func getFlagOrElseConfig(cfg *config.Config, flags *pflag.FlagSet, key string) (
 	return getGHSlackConfigValue(cfg, key)
 }
 
+// getGHSlackConfigValue returns the value of the key from the first of its
+// GH_SLACK_<KEY> environment variable, the repository's configuration, the
+// selected profile, or directly under extensions.slack. A profile chosen with
+// --profile or GH_SLACK_PROFILE comes before the repository's configuration.
 func getGHSlackConfigValue(cfg *config.Config, key string) (string, error) {
-	fullKey := []string{"extensions", "slack", key}
-	s, err := cfg.Get(fullKey)
+	s, _, err := lookupGHSlackConfigValue(cfg, key)
 	if err != nil {
+		fullKey := []string{"extensions", "slack", key}
+		keys := fmt.Sprintf("%q", strings.Join(fullKey, "."))
+		if profile != "" {
+			keys = fmt.Sprintf("%q or %s", strings.Join(profileKey(profile, key), "."), keys)
+		}
+
 		return "", fmt.Errorf(
-			"failed to read gh-slack configuration value %q from %q: %w",
-			strings.Join(fullKey, "."),
+			"failed to read gh-slack configuration value %s from %q (or %s): %w",
+			keys,
 			filepath.Join(config.ConfigDir(), "config.yml"),
+			configEnvVar(key),
 			err)
 	}
 
 	return s, nil
 }
 
+// configEnvVar names the environment variable that overrides the key.
+func configEnvVar(key string) string {
+	return "GH_SLACK_" + strings.ToUpper(key)
+}
+
+// lookupGHSlackConfigValue is getGHSlackConfigValue, also returning where the
+// value came from.
+func lookupGHSlackConfigValue(cfg *config.Config, key string) (string, string, error) {
+	if s := os.Getenv(configEnvVar(key)); s != "" {
+		return s, "env", nil
+	}
+
+	if profileChosen() {
+		s, ok, err := getProfileConfigValue(cfg, key)
+		if err != nil || ok {
+			return s, fmt.Sprintf("global (profile %s)", profile), err
+		}
+	}
+
+	s, ok, err := getRepoConfigValue(key)
+	if err != nil || ok {
+		return s, "repo", err
+	}
+
+	s, ok, err = getProfileConfigValue(cfg, key)
+	if err != nil || ok {
+		return s, fmt.Sprintf("global (profile %s)", profile), err
+	}
+
+	s, err = cfg.Get([]string{"extensions", "slack", key})
+	return s, "global", err
+}
+
+// getOptionalGHSlackConfigValue is like getGHSlackConfigValue, but returns an
+// empty string if the key is not set.
+func getOptionalGHSlackConfigValue(cfg *config.Config, key string) (string, error) {
+	s, err := getGHSlackConfigValue(cfg, key)
+	var notFound *config.KeyNotFoundError
+	if errors.As(err, &notFound) {
+		return "", nil
+	}
+
+	return s, err
+}
+
+func getDurationConfig(cfg *config.Config, key string, fallback time.Duration) (time.Duration, error) {
+	s, err := getOptionalGHSlackConfigValue(cfg, key)
+	if err != nil || s == "" {
+		return fallback, err
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration for gh-slack configuration value %q: %w", key, err)
+	}
+
+	return d, nil
+}
+
+// getDurationFlagOrElseConfig is getFlagOrElseConfig for durations, falling
+// back to the flag's default if the key is not configured either.
+func getDurationFlagOrElseConfig(cfg *config.Config, flags *pflag.FlagSet, key string) (time.Duration, error) {
+	value, err := flags.GetDuration(key)
+	if err != nil || flags.Changed(key) {
+		return value, err
+	}
+
+	return getDurationConfig(cfg, key, value)
+}
+
+func newLogger() *log.Logger {
+	if verbose {
+		return log.Default()
+	}
+	return log.New(io.Discard, "", log.LstdFlags)
+}
+
+// newSlackClient creates a client for the team, applying any cache settings
+// from gh's configuration.
+func newSlackClient(cfg *config.Config, team string) (*slackclient.SlackClient, error) {
+	authOptions, err := getAuthOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := slackclient.NewWithAuth(team, authOptions, newLogger())
+	if err != nil {
+		return nil, err
+	}
+
+	return client, configureOrClose(cfg, client)
+}
+
+// getAuthOptions reads where credentials should come from, from the auth and
+// token_file keys.
+func getAuthOptions(cfg *config.Config) (slackclient.AuthOptions, error) {
+	var opts slackclient.AuthOptions
+	source, err := getOptionalGHSlackConfigValue(cfg, "auth")
+	if err != nil {
+		return opts, err
+	}
+
+	if source != "" {
+		opts.Source, err = slackclient.ParseAuthSource(source)
+		if err != nil {
+			return opts, err
+		}
+	}
+
+	opts.TokenFile, err = getOptionalGHSlackConfigValue(cfg, "token_file")
+	return opts, err
+}
+
+// newOfflineSlackClient is like newSlackClient, but the client only uses local
+// data and so does not need credentials.
+func newOfflineSlackClient(cfg *config.Config, team string) (*slackclient.SlackClient, error) {
+	client, err := slackclient.NewOffline(team, newLogger())
+	if err != nil {
+		return nil, err
+	}
+
+	return client, configureOrClose(cfg, client)
+}
+
+// configureOrClose is configureSlackClient, closing the client if that fails,
+// as the caller won't use it.
+func configureOrClose(cfg *config.Config, client *slackclient.SlackClient) error {
+	err := configureSlackClient(cfg, client)
+	if err != nil {
+		client.Close()
+	}
+	return err
+}
+
+func configureSlackClient(cfg *config.Config, client *slackclient.SlackClient) error {
+	userTTL, err := getDurationConfig(cfg, "user_cache_ttl", slackclient.DefaultCacheTTL)
+	if err != nil {
+		return err
+	}
+
+	channelTTL, err := getDurationConfig(cfg, "channel_cache_ttl", slackclient.DefaultCacheTTL)
+	if err != nil {
+		return err
+	}
+
+	client.WithCacheTTL(userTTL, channelTTL)
+
+	nameStyle, err := getOptionalGHSlackConfigValue(cfg, "name_style")
+	if err != nil {
+		return err
+	}
+
+	if nameStyle != "" {
+		style, err := slackclient.ParseNameStyle(nameStyle)
+		if err != nil {
+			return err
+		}
+		client.WithNameStyle(style)
+	}
+
+	backend, err := getOptionalGHSlackConfigValue(cfg, "cache_backend")
+	if err != nil || backend == "" {
+		return err
+	}
+
+	cacheBackend, err := slackclient.ParseCacheBackend(backend)
+	if err != nil {
+		return err
+	}
+
+	return client.UseCacheBackend(cacheBackend)
+}
+
 const sendConfigEample = `
   # Example configuration (add to gh's configuration file at $HOME/.config/gh/config.yml):
   extensions:
     slack:
       team: foo
       channel: ops
-      bot: robot        # Can be a user id (most reliable), bot profile name or username`
+      bot: robot        # Can be a user id (most reliable), bot id, app id, bot profile name or username
+      user_cache_ttl: 24h     # Optional, how long to cache user names (default 168h, 0 to never refresh)
+      channel_cache_ttl: 24h  # Optional, how long to cache channel names (default 168h, 0 to never refresh)
+      auth: file              # Optional, where credentials come from: "env", "file", "keyring", "desktop" or "auto" (default)
+      token_file: /run/secrets/slack  # Optional, a file holding a bot (xoxb-) or user (xoxp-) token
+      cache_backend: sqlite   # Optional, store the cache in "json" (default) or "sqlite"
+      archive: true           # Optional, keep messages fetched by read so that they are only fetched once
+      name_style: real        # Optional, name users by "handle" (default), "display" name, "real" name or "both"
+      github_org: my-org      # Optional, name users by the GitHub login of the org member with the same email
+      github_users:           # Optional, name users (by Slack ID, handle or email) by their GitHub login
+        jdoe: octocat
+      profiles:               # Optional, settings for other teams, used with --profile or by read for the permalink's team
+        work:
+          team: bar
+          team_id: T0123ABCD  # Optional, to recognise links that only give the team ID
+          channel: deploys
+          auth: keyring
+          name_style: display`
 
 var rootCmd = &cobra.Command{
 	SilenceUsage:  true,
@@ -57,8 +262,18 @@ var rootCmd = &cobra.Command{
   gh-slack read <slack-permalink>
   gh-slack read -i <issue-url> <slack-permalink>
   gh-slack send -m <message> -c <channel-name> -t <team-name>
+  gh-slack send --profile <profile-name> -m <message>
+  gh-slack chat -c <channel-name> -t <team-name> -b <bot-name>
   gh-slack api post chat.postMessage -b '{"channel":"123","blocks":[...]}
   eval $(gh-slack auth -t <team-name>)
+  gh-slack auth login -t <team-name>
+  gh-slack cache refresh --background -t <team-name>
+  gh-slack search --in <channel-name> --archive <query>
+  gh-slack channels list --member --topic <regexp> -t <team-name>
+  gh-slack users show @<username> -t <team-name>
+  gh-slack export -o <directory> -t <team-name> '#<channel-name>'
+  gh-slack import -t <team-name> <export.zip>
+  gh-slack config list
   ` + sendConfigEample,
 }
 
@@ -76,9 +291,41 @@ var verbose bool = false
 func init() {
 	rootCmd.AddCommand(readCmd)
 	rootCmd.AddCommand(sendCmd)
+	rootCmd.AddCommand(chatCmd)
 	rootCmd.AddCommand(apiCmd)
 	rootCmd.AddCommand(authCmd)
+	rootCmd.AddCommand(cacheCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(searchCmd)
+	rootCmd.AddCommand(channelsCmd)
+	rootCmd.AddCommand(usersCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Show verbose debug information")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "Use the settings of this profile from the configuration")
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Read(nil)
+		if err != nil {
+			return err
+		}
+
+		err = loadRepoConfig(".")
+		if err != nil {
+			return err
+		}
+
+		if profile == "" {
+			profile = os.Getenv(configEnvVar("profile"))
+		}
+		if profile == "" {
+			profile, _, err = getRepoConfigValue("profile")
+			if err != nil {
+				return err
+			}
+		}
+
+		return checkProfile(cfg)
+	}
 	rootCmd.SetHelpTemplate(rootCmdUsageTemplate)
 	rootCmd.SetUsageTemplate(rootCmdUsageTemplate)
 }
@@ -87,7 +334,7 @@ const rootCmdUsageTemplate string = `Usage:{{if .Runnable}}
   {{.UseLine}}{{end}}{{if .HasAvailableSubCommands}}
   {{.CommandPath}} [command]{{end}}
 
-  If no command is specified, the default is "read". The default command also requires a permalink argument <START> for the first message to fetch.
+  If no command is specified, the default is "read". The default command also requires a permalink argument <START> for the first message to fetch, or several to combine.
   Use "gh-slack read --help" for more information about the default command behaviour.{{if gt (len .Aliases) 0}}
 Aliases:
   {{.NameAndAliases}}{{end}}{{if .HasExample}}
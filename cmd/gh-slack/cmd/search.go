@@ -0,0 +1,306 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/config"
+	"github.com/cli/go-gh/v2/pkg/tableprinter"
+	"github.com/rneatherway/gh-slack/internal/slackclient"
+	"github.com/rneatherway/slack/pkg/markdown"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search [flags] <query>...",
+	Short: "Searches Slack messages and optionally archives one of them",
+	Long: `Searches Slack messages and prints each match with its permalink.
+
+The query may use Slack's search modifiers directly, or the flags that add
+them. With --archive, the chosen match is read and output just as "read" would,
+so the usual --details and --issue options apply.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Read(nil)
+		if err != nil {
+			return err
+		}
+
+		modifiers, err := searchModifiersFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		query := searchQuery(args, modifiers)
+		if query == "" {
+			return errors.New("a query or search modifier is required")
+		}
+
+		limit, err := cmd.Flags().GetInt("limit")
+		if err != nil {
+			return err
+		}
+		if limit < 1 {
+			return fmt.Errorf("invalid limit %d, expected at least 1", limit)
+		}
+
+		sortFlag, err := cmd.Flags().GetString("sort")
+		if err != nil {
+			return err
+		}
+		sort, err := slackclient.ParseSearchSort(sortFlag)
+		if err != nil {
+			return err
+		}
+
+		team, err := getFlagOrElseConfig(cfg, cmd.Flags(), "team")
+		if err != nil {
+			return err
+		}
+
+		client, err := newSlackClient(cfg, team)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		matches, total, err := client.SearchMessages(query, sort, limit)
+		if err != nil {
+			return err
+		}
+
+		archive, err := cmd.Flags().GetBool("archive")
+		if err != nil {
+			return err
+		}
+
+		if archive {
+			pick, err := cmd.Flags().GetInt("pick")
+			if err != nil {
+				return err
+			}
+
+			interactive := pick == 0 && len(matches) > 1 && term.IsTerminal(int(os.Stdin.Fd()))
+			if interactive {
+				width, _, err := term.GetSize(int(os.Stderr.Fd()))
+				if err != nil {
+					width = 80
+				}
+				err = printMatches(tableprinter.New(os.Stderr, true, width), client, matches)
+				if err != nil {
+					return err
+				}
+			}
+
+			match, err := chooseMatch(matches, pick, interactive, os.Stdin, os.Stderr)
+			if err != nil {
+				return err
+			}
+
+			return readSlack([]string{match.Permalink})
+		}
+
+		asJSON, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			return err
+		}
+
+		if asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(searchResult{Query: query, Total: total, Matches: matches})
+		}
+
+		fmt.Fprintf(os.Stderr, "Showing %d of %d messages matching %q\n", len(matches), total, query)
+		return printMatches(newTablePrinter(), client, matches)
+	},
+	Example: `  gh-slack search -t <team-name> deploy failed
+  gh-slack search --in ops --from @alice --after 2024-03-01 --has link rollback
+  gh-slack search "in:#ops has:pin" --sort timestamp --limit 50 --json
+  gh-slack search --in ops --archive --details --issue <issue-url> outage`,
+}
+
+type searchResult struct {
+	Query   string                    `json:"query"`
+	Total   int                       `json:"total"`
+	Matches []slackclient.SearchMatch `json:"matches"`
+}
+
+var userIDRE = regexp.MustCompile("^[UW][A-Z0-9]{8,}$")
+
+// searchModifiers are Slack search modifiers given by flags rather than in
+// the query itself.
+type searchModifiers struct {
+	in, from, has         []string
+	after, before, during string
+}
+
+func searchModifiersFromFlags(cmd *cobra.Command) (searchModifiers, error) {
+	var m searchModifiers
+	var err error
+	for name, value := range map[string]*[]string{"in": &m.in, "from": &m.from, "has": &m.has} {
+		*value, err = cmd.Flags().GetStringSlice(name)
+		if err != nil {
+			return m, err
+		}
+	}
+	for name, value := range map[string]*string{"after": &m.after, "before": &m.before, "on": &m.during} {
+		*value, err = cmd.Flags().GetString(name)
+		if err != nil {
+			return m, err
+		}
+	}
+	return m, nil
+}
+
+// searchQuery joins the terms of a query with the modifiers given by flags.
+// Channels and users may be given by name, with or without their # or @, or
+// by ID.
+func searchQuery(terms []string, m searchModifiers) string {
+	parts := make([]string, 0, len(terms))
+	for _, word := range terms {
+		if word = strings.TrimSpace(word); word != "" {
+			parts = append(parts, word)
+		}
+	}
+
+	for _, channel := range m.in {
+		switch {
+		case strings.HasPrefix(channel, "#"), strings.HasPrefix(channel, "@"), strings.HasPrefix(channel, "<"):
+		case channelIDRE.MatchString(channel):
+			channel = "<#" + channel + ">"
+		default:
+			channel = "#" + channel
+		}
+		parts = append(parts, "in:"+channel)
+	}
+
+	for _, user := range m.from {
+		switch {
+		case strings.HasPrefix(user, "@"), strings.HasPrefix(user, "<"):
+		case userIDRE.MatchString(user):
+			user = "<@" + user + ">"
+		default:
+			user = "@" + user
+		}
+		parts = append(parts, "from:"+user)
+	}
+
+	for _, has := range m.has {
+		parts = append(parts, "has:"+has)
+	}
+
+	for _, date := range []struct{ modifier, value string }{
+		{"after", m.after},
+		{"before", m.before},
+		{"on", m.during},
+	} {
+		if date.value != "" {
+			parts = append(parts, date.modifier+":"+date.value)
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func printMatches(t tableprinter.TablePrinter, client *slackclient.SlackClient, matches []slackclient.SearchMatch) error {
+	t.AddHeader([]string{"#", "CHANNEL", "USER", "TIME", "TEXT", "PERMALINK"})
+	for i, match := range matches {
+		tm, err := markdown.ParseUnixTimestamp(match.Ts)
+		if err != nil {
+			return err
+		}
+
+		t.AddField(strconv.Itoa(i + 1))
+		t.AddField(matchChannel(match.Channel))
+		t.AddField(match.Username)
+		t.AddField(tm.In(client.GetLocation()).Format("2006-01-02 15:04"))
+		t.AddField(strings.Join(strings.Fields(match.Text), " "))
+		t.AddField(match.Permalink)
+		t.EndRow()
+	}
+	return t.Render()
+}
+
+func matchChannel(channel slackclient.SearchChannel) string {
+	switch {
+	case channel.IsIM:
+		return "DM"
+	case channel.IsMpim:
+		return channel.Name
+	}
+	return "#" + channel.Name
+}
+
+// chooseMatch returns the match to archive: the one picked by number, the
+// only one, or one chosen at a prompt.
+func chooseMatch(matches []slackclient.SearchMatch, pick int, interactive bool, in io.Reader, out io.Writer) (slackclient.SearchMatch, error) {
+	if len(matches) == 0 {
+		return slackclient.SearchMatch{}, errors.New("no messages found to archive")
+	}
+
+	if pick == 0 && len(matches) == 1 {
+		pick = 1
+	}
+
+	if pick == 0 {
+		if !interactive {
+			return slackclient.SearchMatch{}, fmt.Errorf("%d messages found, choose one to archive with --pick", len(matches))
+		}
+
+		fmt.Fprintf(out, "Archive which message? [1-%d]: ", len(matches))
+		line, err := bufio.NewReader(in).ReadString('\n')
+		if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+			return slackclient.SearchMatch{}, err
+		}
+
+		pick, err = strconv.Atoi(strings.TrimSpace(line))
+		if err != nil {
+			return slackclient.SearchMatch{}, fmt.Errorf("invalid choice %q", strings.TrimSpace(line))
+		}
+	}
+
+	if pick < 1 || pick > len(matches) {
+		return slackclient.SearchMatch{}, fmt.Errorf("invalid choice %d, expected 1 to %d", pick, len(matches))
+	}
+
+	match := matches[pick-1]
+	if match.Permalink == "" {
+		return slackclient.SearchMatch{}, fmt.Errorf("message %d has no permalink", pick)
+	}
+	return match, nil
+}
+
+func init() {
+	searchCmd.Flags().StringP("team", "t", "", "Slack team name (required here or in config)")
+	searchCmd.Flags().StringSlice("in", nil, "Only messages in these channels (name or ID) or DMs (@user)")
+	searchCmd.Flags().StringSlice("from", nil, "Only messages from these users (handle or ID)")
+	searchCmd.Flags().StringSlice("has", nil, "Only messages that have these: link, pin, star, reaction or an :emoji: reaction")
+	searchCmd.Flags().String("after", "", "Only messages after this date, e.g. 2024-03-01 or yesterday")
+	searchCmd.Flags().String("before", "", "Only messages before this date")
+	searchCmd.Flags().String("on", "", "Only messages on this date")
+	searchCmd.Flags().String("sort", string(slackclient.SearchSortScore), "Order the results by score (relevance) or timestamp (most recent first)")
+	searchCmd.Flags().IntP("limit", "l", 20, "Maximum number of messages to fetch, in pages of up to 100")
+	searchCmd.Flags().Bool("json", false, "Output the messages as JSON")
+	searchCmd.Flags().Bool("archive", false, "Read the chosen message as \"read\" would, instead of listing the messages")
+	searchCmd.Flags().Int("pick", 0, "Number of the message to --archive, instead of choosing at a prompt")
+	searchCmd.MarkFlagsMutuallyExclusive("archive", "json")
+
+	// These are shared with read, for --archive.
+	searchCmd.Flags().BoolVarP(&opts.Details, "details", "d", false, "With --archive, wrap the markdown output in HTML <details> tags")
+	searchCmd.Flags().StringVarP(&opts.Issue, "issue", "i", "", "With --archive, the URL of a repository to post the output as a new issue, or of an issue (or pull request) to comment on")
+	searchCmd.Flags().StringVar(&opts.NameStyle, "name-style", "", "With --archive, how to name users: handle, display, real or both")
+	searchCmd.Flags().StringVar(&opts.GitHubUsers, "github-users", "", "With --archive, YAML file mapping Slack users to GitHub logins")
+	searchCmd.Flags().StringVar(&opts.GitHubOrg, "github-org", "", "With --archive, map Slack users to members of this GitHub organization with the same email")
+	searchCmd.Flags().BoolVar(&opts.Mention, "mention", false, "With --archive, @-mention the GitHub users that Slack users are mapped to")
+
+	searchCmd.SetUsageTemplate(sendCmdUsage)
+	searchCmd.SetHelpTemplate(sendCmdUsage)
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rneatherway/gh-slack/internal/slackclient"
+)
+
+func TestSearchQuery(t *testing.T) {
+	for _, test := range []struct {
+		terms     []string
+		modifiers searchModifiers
+		expected  string
+	}{
+		{[]string{"deploy", "failed"}, searchModifiers{}, "deploy failed"},
+		{[]string{"in:#ops has:pin"}, searchModifiers{}, "in:#ops has:pin"},
+		{
+			[]string{"rollback"},
+			searchModifiers{
+				in:    []string{"ops", "#dev", "C0123ABCD", "@alice"},
+				from:  []string{"bob", "@carol", "U0123ABCD"},
+				has:   []string{"link", ":eyes:"},
+				after: "2024-03-01", before: "2024-04-01", during: "yesterday",
+			},
+			"rollback in:#ops in:#dev in:<#C0123ABCD> in:@alice from:@bob from:@carol from:<@U0123ABCD> has:link has::eyes: after:2024-03-01 before:2024-04-01 on:yesterday",
+		},
+		{[]string{" "}, searchModifiers{}, ""},
+	} {
+		actual := searchQuery(test.terms, test.modifiers)
+		if actual != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, actual)
+		}
+	}
+}
+
+func TestChooseMatch(t *testing.T) {
+	matches := []slackclient.SearchMatch{
+		{Text: "one", Permalink: "https://example.slack.com/archives/C0123ABCD/p1709663536325529"},
+		{Text: "two", Permalink: "https://example.slack.com/archives/C0123ABCD/p1709663536325530"},
+	}
+
+	for _, test := range []struct {
+		matches     []slackclient.SearchMatch
+		pick        int
+		interactive bool
+		input       string
+		expected    string
+	}{
+		{matches, 2, false, "", "two"},
+		{matches[:1], 0, false, "", "one"},
+		{matches, 0, true, "2\n", "two"},
+		{matches, 0, true, "1", "one"},
+		{matches, 0, false, "", ""},
+		{matches, 3, false, "", ""},
+		{matches, 0, true, "three\n", ""},
+		{nil, 0, true, "1\n", ""},
+	} {
+		out := &strings.Builder{}
+		match, err := chooseMatch(test.matches, test.pick, test.interactive, strings.NewReader(test.input), out)
+		if test.expected == "" && err == nil {
+			t.Errorf("expected an error picking %d (%q), got %q", test.pick, test.input, match.Text)
+		} else if test.expected != "" && match.Text != test.expected {
+			t.Errorf("expected %q picking %d (%q), got %q (%v)", test.expected, test.pick, test.input, match.Text, err)
+		}
+	}
+}
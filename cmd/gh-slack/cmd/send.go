@@ -2,8 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"io"
-	"log"
 	"time"
 
 	"github.com/cli/go-gh/v2/pkg/config"
@@ -21,11 +19,19 @@ var sendCmd = &cobra.Command{
 			return err
 		}
 
-		channelName, err := getFlagOrElseConfig(cfg, cmd.Flags(), "channel")
+		users, err := cmd.Flags().GetStringSlice("user")
 		if err != nil {
 			return err
 		}
 
+		var channelName string
+		if len(users) == 0 {
+			channelName, err = getFlagOrElseConfig(cfg, cmd.Flags(), "channel")
+			if err != nil {
+				return err
+			}
+		}
+
 		team, err := getFlagOrElseConfig(cfg, cmd.Flags(), "team")
 		if err != nil {
 			return err
@@ -53,39 +59,57 @@ var sendCmd = &cobra.Command{
 			}
 		}
 
-		timeout, err := cmd.Flags().GetDuration("timeout")
+		timeout, err := getDurationFlagOrElseConfig(cfg, cmd.Flags(), "timeout")
+		if err != nil {
+			return err
+		}
+
+		transportFlag, err := cmd.Flags().GetString("transport")
+		if err != nil {
+			return err
+		}
+
+		transport, err := slackclient.ParseTransport(transportFlag)
 		if err != nil {
 			return err
 		}
 
-		logger := log.New(io.Discard, "", log.LstdFlags)
-		if verbose {
-			logger = log.Default()
+		client, err := newSlackClient(cfg, team)
+		if err != nil {
+			return err
 		}
-		return sendMessage(team, channelName, message, bot, timeout, logger)
+		defer client.Close()
+		return sendMessage(client, team, channelName, users, message, bot, timeout, transport)
 	},
 	Example: `  gh-slack send -t <team-name> -c <channel-name> -m <message> -b <bot-name>
   gh-slack send -m <message> -w # If bot is specified in config
+  gh-slack send -m <message> -u @alice # Direct message
+  gh-slack send -m <message> -u @alice,@bob # Group DM
+  SLACK_APP_TOKEN=xapp-... gh-slack send -m <message> -w # Wait using Socket Mode
 ` + sendConfigEample,
 }
 
-// sendMessage sends a message to a Slack channel.
-func sendMessage(team, channelName, message, bot string, timeout time.Duration, logger *log.Logger) error {
-	client, err := slackclient.New(team, logger)
-	if err != nil {
-		return err
-	}
-
-	var rtmClient *slackclient.RTMClient
+// sendMessage sends a message to a Slack channel, or to a direct message with
+// the given users if any.
+func sendMessage(client *slackclient.SlackClient, team, channelName string, users []string, message, bot string, timeout time.Duration, transport slackclient.Transport) error {
+	var err error
+	var stream slackclient.EventStream
+	var botIdentity *slackclient.BotIdentity
 	if bot != "" {
-		rtmClient, err = client.ConnectToRTM()
+		botIdentity, err = client.ResolveBotIdentity(bot)
+		if err != nil {
+			return err
+		}
+		newLogger().Printf("Waiting for a response from %s", botIdentity)
+
+		stream, err = client.ConnectToEventStream(transport)
 		if err != nil {
 			return err
 		}
-		defer rtmClient.Close()
+		defer stream.Close()
 	}
 
-	channelID, err := client.ChannelIDForName(channelName)
+	channelID, err := conversationID(client, channelName, users)
 	if err != nil {
 		return err
 	}
@@ -97,7 +121,7 @@ func sendMessage(team, channelName, message, bot string, timeout time.Duration,
 	fmt.Println(resp.Output(team, channelID))
 
 	if bot != "" {
-		err = rtmClient.ListenForMessagesFromBot(channelID, bot, timeout)
+		err = client.ListenForMessagesFromBot(stream, channelID, botIdentity, timeout)
 		if err != nil {
 			return fmt.Errorf("failed to listen to messages: %w", err)
 		}
@@ -106,14 +130,34 @@ func sendMessage(team, channelName, message, bot string, timeout time.Duration,
 	return nil
 }
 
+func conversationID(client *slackclient.SlackClient, channelName string, users []string) (string, error) {
+	if len(users) == 0 {
+		return client.ChannelIDForName(channelName)
+	}
+
+	userIDs := make([]string, 0, len(users))
+	for _, user := range users {
+		id, err := client.UserIDForName(user)
+		if err != nil {
+			return "", err
+		}
+		userIDs = append(userIDs, id)
+	}
+
+	return client.OpenConversation(userIDs...)
+}
+
 func init() {
-	sendCmd.Flags().StringP("channel", "c", "", "Channel name to send the message to (required here or in config)")
+	sendCmd.Flags().StringP("channel", "c", "", "Channel name to send the message to (required here, with --user, or in config)")
+	sendCmd.Flags().StringSliceP("user", "u", nil, "Usernames (e.g. @alice) to send a direct message to, instead of a channel. Several users make a group DM")
+	sendCmd.MarkFlagsMutuallyExclusive("channel", "user")
 	sendCmd.Flags().StringP("message", "m", "", "Message to send (required here or in config)")
 	sendCmd.Flags().StringP("team", "t", "", "Slack team name (required here or in config)")
 	sendCmd.MarkFlagRequired("message")
-	sendCmd.Flags().StringP("bot", "b", "", "User id (most reliable), profile name or username to wait for a response from (implies --wait)")
+	sendCmd.Flags().StringP("bot", "b", "", "User id (most reliable), bot id, app id, profile name or username to wait for a response from (implies --wait)")
 	sendCmd.Flags().BoolP("wait", "w", false, "Wait for message responses")
 	sendCmd.Flags().Duration("timeout", 60*time.Second, "Timeout for waiting for bot response (e.g., 30s, 2m)")
+	sendCmd.Flags().String("transport", string(slackclient.TransportAuto), "How to listen for responses: auto, rtm or socket (Socket Mode, requires $SLACK_APP_TOKEN)")
 	sendCmd.MarkFlagsRequiredTogether("message")
 	sendCmd.SetUsageTemplate(sendCmdUsage)
 	sendCmd.SetHelpTemplate(sendCmdUsage)
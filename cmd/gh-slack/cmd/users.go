@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rneatherway/gh-slack/internal/slackclient"
+	"github.com/spf13/cobra"
+)
+
+var usersCmd = &cobra.Command{
+	Use:   "users <command>",
+	Short: "Lists and shows the users in a Slack workspace",
+	Long:  "Lists and shows the users in a Slack workspace.",
+	Example: `  gh-slack users list -t <team-name>
+  gh-slack users list --bots --json
+  gh-slack users show @alice
+  gh-slack users show alice@example.com`,
+}
+
+// userSummary is a user as output by "users list --json" and "users show
+// --json".
+type userSummary struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	RealName    string `json:"real_name"`
+	DisplayName string `json:"display_name"`
+	Title       string `json:"title"`
+	Email       string `json:"email"`
+	TZ          string `json:"tz"`
+	Bot         bool   `json:"bot"`
+	Deleted     bool   `json:"deleted"`
+}
+
+func summarizeUser(user slackclient.User) userSummary {
+	realName := user.RealName
+	if realName == "" {
+		realName = user.Profile.RealName
+	}
+
+	return userSummary{
+		ID:          user.ID,
+		Name:        user.Name,
+		RealName:    realName,
+		DisplayName: user.Profile.DisplayName,
+		Title:       user.Profile.Title,
+		Email:       user.Profile.Email,
+		TZ:          user.TZ,
+		Bot:         user.IsBot,
+		Deleted:     user.Deleted,
+	}
+}
+
+// filterUsers returns the users to list, sorted by handle. Bots and
+// deactivated users are only included if asked for.
+func filterUsers(users []slackclient.User, bots, deleted bool) []userSummary {
+	summaries := make([]userSummary, 0, len(users))
+	for _, user := range users {
+		if user.IsBot && !bots || user.Deleted && !deleted {
+			continue
+		}
+		summaries = append(summaries, summarizeUser(user))
+	}
+
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Name < summaries[j].Name })
+	return summaries
+}
+
+var usersListCmd = &cobra.Command{
+	Use:   "list [flags]",
+	Short: "Prints the users",
+	Long:  "Prints the users in the workspace.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		bots, err := cmd.Flags().GetBool("bots")
+		if err != nil {
+			return err
+		}
+
+		deleted, err := cmd.Flags().GetBool("deleted")
+		if err != nil {
+			return err
+		}
+
+		asJSON, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			return err
+		}
+
+		client, err := directoryClient(cmd.Flags())
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		users, err := client.ListUsers()
+		if err != nil {
+			return err
+		}
+
+		summaries := filterUsers(users, bots, deleted)
+
+		if asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(summaries)
+		}
+
+		t := newTablePrinter()
+		t.AddHeader([]string{"HANDLE", "ID", "NAME", "DISPLAY NAME", "EMAIL"})
+		for _, user := range summaries {
+			t.AddField("@" + user.Name)
+			t.AddField(user.ID)
+			t.AddField(user.RealName)
+			t.AddField(user.DisplayName)
+			t.AddField(user.Email)
+			t.EndRow()
+		}
+		return t.Render()
+	},
+}
+
+var usersShowCmd = &cobra.Command{
+	Use:   "show [flags] <user>",
+	Short: "Prints a user's profile",
+	Long:  "Prints the profile of a user, given by ID, handle (e.g. @alice) or email.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asJSON, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			return err
+		}
+
+		client, err := directoryClient(cmd.Flags())
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		user, err := findUser(client, args[0])
+		if err != nil {
+			return err
+		}
+
+		summary := summarizeUser(*user)
+
+		if asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(summary)
+		}
+
+		t := newTablePrinter()
+		for _, field := range [][2]string{
+			{"ID", summary.ID},
+			{"Handle", "@" + summary.Name},
+			{"Name", summary.RealName},
+			{"Display name", summary.DisplayName},
+			{"Title", summary.Title},
+			{"Email", summary.Email},
+			{"Time zone", summary.TZ},
+			{"Bot", strconv.FormatBool(summary.Bot)},
+			{"Deactivated", strconv.FormatBool(summary.Deleted)},
+		} {
+			t.AddField(field[0])
+			t.AddField(field[1])
+			t.EndRow()
+		}
+		return t.Render()
+	},
+}
+
+// findUser looks up a user by ID, email or handle.
+func findUser(client *slackclient.SlackClient, user string) (*slackclient.User, error) {
+	switch {
+	case userIDRE.MatchString(user):
+		return client.UserForID(user)
+	case strings.Contains(strings.TrimPrefix(user, "@"), "@"):
+		return client.UserForEmail(user)
+	}
+
+	id, err := client.UserIDForName(user)
+	if err != nil {
+		return nil, err
+	}
+	return client.UserForID(id)
+}
+
+func init() {
+	usersCmd.PersistentFlags().StringP("team", "t", "", "Slack team name (required here or in config)")
+	usersCmd.PersistentFlags().Bool("json", false, "Output the users as JSON")
+	usersListCmd.Flags().Bool("bots", false, "Include bots")
+	usersListCmd.Flags().Bool("deleted", false, "Include deactivated users")
+
+	usersCmd.SetUsageTemplate(sendCmdUsage)
+	usersCmd.SetHelpTemplate(sendCmdUsage)
+	usersCmd.AddCommand(usersListCmd, usersShowCmd)
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/rneatherway/gh-slack/internal/mocks"
+	"github.com/rneatherway/gh-slack/internal/slackclient"
+)
+
+func TestFilterUsers(t *testing.T) {
+	users := []slackclient.User{
+		{ID: "U2", Name: "bob", Profile: slackclient.UserProfile{RealName: "Bob", Email: "bob@example.com"}},
+		{ID: "U1", Name: "alice", RealName: "Alice"},
+		{ID: "U3", Name: "robot", IsBot: true},
+		{ID: "U4", Name: "carol", Deleted: true},
+	}
+
+	actual := filterUsers(users, false, false)
+	if len(actual) != 2 || actual[0].Name != "alice" || actual[1].RealName != "Bob" || actual[1].Email != "bob@example.com" {
+		t.Errorf("unexpected users %+v", actual)
+	}
+
+	actual = filterUsers(users, true, true)
+	if len(actual) != 4 {
+		t.Errorf("expected bots and deactivated users to be included, got %+v", actual)
+	}
+}
+
+func TestFindUser(t *testing.T) {
+	mockClient := &mocks.MockClient{}
+	mockClient.MockResponses(map[string]string{
+		"users.info?user=U0123ABCD":                   `{"ok":true,"user":{"id":"U0123ABCD","name":"alice"}}`,
+		"users.lookupByEmail?email=bob%40example.com": `{"ok":true,"user":{"id":"U0456ABCD","name":"bob"}}`,
+	}, nil)
+
+	client, err := slackclient.Null("test", mockClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for query, expected := range map[string]string{
+		"U0123ABCD":       "alice",
+		"bob@example.com": "bob",
+	} {
+		user, err := findUser(client, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.Name != expected {
+			t.Errorf("expected %q to find %s, got %s", query, expected, user.Name)
+		}
+	}
+}
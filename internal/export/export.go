@@ -0,0 +1,581 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rneatherway/gh-slack/internal/markdown"
+	"github.com/rneatherway/gh-slack/internal/slackclient"
+)
+
+// stateFile records the progress of an export, so that it can be resumed,
+// or brought up to date, by exporting to the same directory again.
+const stateFile = ".gh-slack-export.json"
+
+type state struct {
+	ChannelID string `json:"channel_id"`
+	// History is the pass over the channel's history in progress, if any.
+	History *historyPass `json:"history,omitempty"`
+	// Threads maps each exported thread to its latest reply at the time.
+	Threads map[string]string `json:"threads"`
+}
+
+// historyPass records how far a pass over the channel's history, which is
+// fetched newest first, has got, so that an interrupted pass can continue.
+type historyPass struct {
+	// Latest bounds the pages being fetched, and Cursor is the next of them.
+	Latest string `json:"latest,omitempty"`
+	Cursor string `json:"cursor"`
+	// Oldest is the oldest message fetched so far, before which the pass
+	// starts again if Slack no longer accepts the cursor.
+	Oldest string `json:"oldest,omitempty"`
+}
+
+// Options control an export.
+type Options struct {
+	// Files downloads the content of the files shared in the channel.
+	Files bool
+	// Markdown controls how messages are rendered.
+	Markdown markdown.Options
+	// Progress, if set, receives progress messages.
+	Progress io.Writer
+}
+
+type exporter struct {
+	client     *slackclient.SlackClient
+	channel    *slackclient.Channel
+	name       string
+	dir        string
+	fsys       fs.FS
+	channelDir string
+	state      state
+	opts       Options
+}
+
+// Write exports the whole history of a channel, including threads and
+// (optionally) files, to dir. If dir already holds an export of the channel,
+// only threads and files that are new or have changed since are fetched, so
+// an interrupted export can be resumed by running it again.
+func Write(client *slackclient.SlackClient, channelID, dir string, opts Options) error {
+	channel, err := client.ChannelInfo(channelID)
+	if err != nil {
+		return err
+	}
+
+	name := channel.Name
+	if name == "" {
+		name = channel.ID
+	}
+
+	e := &exporter{
+		client:     client,
+		channel:    channel,
+		name:       name,
+		dir:        dir,
+		fsys:       os.DirFS(dir),
+		channelDir: filepath.Join(dir, name),
+		opts:       opts,
+	}
+
+	err = e.loadState()
+	if err != nil {
+		return err
+	}
+
+	for _, step := range []func() error{e.metadata, e.history, e.threads, e.files, e.render} {
+		err = step()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *exporter) progress(format string, args ...any) {
+	if e.opts.Progress != nil {
+		fmt.Fprintf(e.opts.Progress, format, args...)
+	}
+}
+
+func (e *exporter) loadState() error {
+	e.state = state{ChannelID: e.channel.ID, Threads: map[string]string{}}
+
+	content, err := os.ReadFile(filepath.Join(e.dir, stateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(content, &e.state)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", filepath.Join(e.dir, stateFile), err)
+	}
+
+	if e.state.ChannelID != e.channel.ID {
+		return fmt.Errorf("%s already holds an export of another channel (%s)", e.dir, e.state.ChannelID)
+	}
+	if e.state.Threads == nil {
+		e.state.Threads = map[string]string{}
+	}
+
+	e.progress("Resuming the export in %s\n", e.dir)
+	return nil
+}
+
+func (e *exporter) saveState() error {
+	return writeJSON(filepath.Join(e.dir, stateFile), e.state)
+}
+
+// metadata writes channels.json and users.json.
+func (e *exporter) metadata() error {
+	members, err := e.client.ConversationMembers(e.channel.ID)
+	if err != nil {
+		return err
+	}
+
+	err = writeJSON(filepath.Join(e.dir, channelsFile), []Channel{{
+		ID:         e.channel.ID,
+		Name:       e.name,
+		Created:    e.channel.Created,
+		Creator:    e.channel.Creator,
+		IsArchived: e.channel.Is_Archived,
+		Members:    members,
+		Topic:      Text{Value: e.channel.Topic.Value},
+		Purpose:    Text{Value: e.channel.Purpose.Value},
+	}})
+	if err != nil {
+		return err
+	}
+
+	users, err := e.client.ListUsers()
+	if err != nil {
+		return err
+	}
+
+	exported := make([]User, len(users))
+	for i, user := range users {
+		exported[i] = User{
+			ID:       user.ID,
+			Name:     user.Name,
+			Deleted:  user.Deleted,
+			RealName: user.RealName,
+			TZ:       user.TZ,
+			IsBot:    user.IsBot,
+			Profile:  user.Profile,
+		}
+	}
+
+	return writeJSON(filepath.Join(e.dir, usersFile), exported)
+}
+
+// addMessages merges messages into their day files, replacing any copies
+// already there.
+func (e *exporter) addMessages(messages []json.RawMessage) error {
+	byDay := map[string][]json.RawMessage{}
+	for _, message := range messages {
+		f, err := parseFields(message)
+		if err != nil {
+			return err
+		}
+
+		day, err := dayOf(f.Ts)
+		if err != nil {
+			return err
+		}
+		byDay[day] = append(byDay[day], message)
+	}
+
+	for day, messages := range byDay {
+		existing, err := ReadDay(e.fsys, e.name, day)
+		if err != nil {
+			return err
+		}
+
+		byTS := map[string]json.RawMessage{}
+		for _, message := range append(existing, messages...) {
+			f, err := parseFields(message)
+			if err != nil {
+				return err
+			}
+			byTS[f.Ts] = message
+		}
+
+		timestamps := make([]string, 0, len(byTS))
+		for ts := range byTS {
+			timestamps = append(timestamps, ts)
+		}
+		slices.SortFunc(timestamps, compareTimestamps)
+
+		merged := make([]json.RawMessage, len(timestamps))
+		for i, ts := range timestamps {
+			merged[i] = byTS[ts]
+		}
+
+		err = writeJSON(filepath.Join(e.channelDir, day+".json"), merged)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// history fetches the channel's messages. Once a pass over the whole history
+// has finished, the next export starts a new one, as only the copy of a
+// thread's root in the channel history shows that the thread has new replies.
+// An interrupted pass continues where it got to.
+func (e *exporter) history() error {
+	pass := e.state.History
+	if pass == nil {
+		pass = &historyPass{}
+	} else {
+		e.progress("Continuing the history of #%s from %s\n", e.name, pass.Oldest)
+	}
+
+	count := 0
+	for {
+		page, err := e.client.HistoryPage(e.channel.ID, pass.Latest, pass.Cursor)
+		if errors.Is(err, slackclient.ErrInvalidCursor) && pass.Cursor != "" {
+			e.progress("Continuing from the oldest message exported, as Slack no longer accepts the saved cursor\n")
+			pass = &historyPass{Latest: pass.Oldest, Oldest: pass.Oldest}
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		err = e.addMessages(page.Messages)
+		if err != nil {
+			return err
+		}
+
+		for _, message := range page.Messages {
+			f, err := parseFields(message)
+			if err != nil {
+				return err
+			}
+			if pass.Oldest == "" || compareTimestamps(f.Ts, pass.Oldest) < 0 {
+				pass.Oldest = f.Ts
+			}
+		}
+
+		count += len(page.Messages)
+		e.progress("Exported %d messages from #%s\n", count, e.name)
+
+		pass.Cursor = page.ResponseMetadata.NextCursor
+		if pass.Cursor == "" {
+			break
+		}
+
+		e.state.History = pass
+		err = e.saveState()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Only now can the thread roots be relied on to be up to date.
+	e.state.History = nil
+	return e.saveState()
+}
+
+// forEachMessage calls f with each exported message, oldest first.
+func (e *exporter) forEachMessage(f func(day string, raw json.RawMessage, fields fields) error) error {
+	days, err := Days(e.fsys, e.name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, day := range days {
+		messages, err := ReadDay(e.fsys, e.name, day)
+		if err != nil {
+			return err
+		}
+
+		for _, message := range messages {
+			fields, err := parseFields(message)
+			if err != nil {
+				return err
+			}
+
+			err = f(day, message, fields)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// threads fetches each thread that has new replies since the last export.
+func (e *exporter) threads() error {
+	var parents []fields
+	err := e.forEachMessage(func(_ string, _ json.RawMessage, f fields) error {
+		if f.ReplyCount > 0 && f.ThreadTs == f.Ts {
+			parents = append(parents, f)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, parent := range parents {
+		if parent.LatestReply != "" && e.state.Threads[parent.Ts] == parent.LatestReply {
+			continue
+		}
+
+		e.progress("Exporting thread %d of %d\n", i+1, len(parents))
+
+		var replies []json.RawMessage
+		cursor := ""
+		for {
+			page, err := e.client.RepliesPage(e.channel.ID, parent.Ts, cursor)
+			if err != nil {
+				return err
+			}
+
+			replies = append(replies, page.Messages...)
+			cursor = page.ResponseMetadata.NextCursor
+			if cursor == "" {
+				break
+			}
+		}
+
+		err = e.addMessages(replies)
+		if err != nil {
+			return err
+		}
+
+		latest := parent.LatestReply
+		for _, reply := range replies {
+			f, err := parseFields(reply)
+			if err != nil {
+				return err
+			}
+			if compareTimestamps(f.Ts, latest) > 0 {
+				latest = f.Ts
+			}
+		}
+
+		e.state.Threads[parent.Ts] = latest
+		err = e.saveState()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// files downloads each shared file that has not already been downloaded.
+func (e *exporter) files() error {
+	if !e.opts.Files {
+		return nil
+	}
+
+	return e.forEachMessage(func(_ string, _ json.RawMessage, f fields) error {
+		for _, file := range f.Files {
+			if !file.downloadable() {
+				continue
+			}
+
+			path := filepath.Join(e.channelDir, filesDir, file.localName())
+			_, err := os.Stat(path)
+			if err == nil {
+				continue
+			} else if !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+
+			e.progress("Downloading %s\n", file.Name)
+			err = e.download(file, path)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (e *exporter) download(file File, path string) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".download.*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	err = e.client.DownloadFile(file.URLPrivateDownload, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// dayPage is the content of a day's markdown file.
+type dayPage struct {
+	day      string
+	messages []slackclient.Message
+	threads  []fields
+	files    []File
+	count    int
+}
+
+// render writes the markdown for each day and thread, and the index. They
+// are always rendered again from the day files, so that they include
+// everything exported so far.
+func (e *exporter) render() error {
+	var pages []*dayPage
+	threads := map[string][]slackclient.Message{}
+	threadDays := map[string]string{}
+
+	err := e.forEachMessage(func(day string, raw json.RawMessage, f fields) error {
+		if len(pages) == 0 || pages[len(pages)-1].day != day {
+			pages = append(pages, &dayPage{day: day})
+		}
+		page := pages[len(pages)-1]
+		page.count++
+
+		var message slackclient.Message
+		err := json.Unmarshal(raw, &message)
+		if err != nil {
+			return err
+		}
+
+		if f.inChannel() {
+			page.messages = append(page.messages, message)
+		}
+		if f.ThreadTs != "" {
+			threads[f.ThreadTs] = append(threads[f.ThreadTs], message)
+		}
+		if f.ReplyCount > 0 && f.ThreadTs == f.Ts {
+			page.threads = append(page.threads, f)
+			threadDays[f.Ts] = day
+		}
+		for _, file := range f.Files {
+			if file.downloadable() && e.opts.Files {
+				page.files = append(page.files, file)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, page := range pages {
+		err = e.renderDay(page)
+		if err != nil {
+			return err
+		}
+	}
+
+	for ts, messages := range threads {
+		day, ok := threadDays[ts]
+		if !ok {
+			// The root of the thread hasn't been exported.
+			continue
+		}
+
+		err = e.renderThread(ts, day, messages)
+		if err != nil {
+			return err
+		}
+	}
+
+	return e.renderIndex(pages)
+}
+
+func (e *exporter) renderMessages(messages []slackclient.Message) (string, error) {
+	return markdown.FromMessagesWithOptions(e.client, &slackclient.HistoryResponse{Ok: true, Messages: messages}, e.opts.Markdown)
+}
+
+func (e *exporter) renderDay(page *dayPage) error {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "# #%s on %s\n\n", e.name, page.day)
+
+	s, err := e.renderMessages(page.messages)
+	if err != nil {
+		return err
+	}
+	b.WriteString(s)
+
+	if len(page.threads) > 0 {
+		b.WriteString("\n## Threads\n\n")
+		for _, thread := range page.threads {
+			t, err := timestampTime(thread.Ts)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(b, "- [Thread from %s](%s/%s.md), %d replies\n",
+				t.In(e.client.GetLocation()).Format("15:04 MST"), threadsDir, thread.Ts, thread.ReplyCount)
+		}
+	}
+
+	if len(page.files) > 0 {
+		b.WriteString("\n## Files\n\n")
+		for _, file := range page.files {
+			fmt.Fprintf(b, "- [%s](%s/%s)\n", file.Name, filesDir, url.PathEscape(file.localName()))
+		}
+	}
+
+	return writeFile(filepath.Join(e.channelDir, page.day+".md"), []byte(b.String()))
+}
+
+func (e *exporter) renderThread(ts, day string, messages []slackclient.Message) error {
+	s, err := e.renderMessages(messages)
+	if err != nil {
+		return err
+	}
+
+	content := fmt.Sprintf("# Thread in #%s\n\nFrom [%s](../%s.md).\n\n%s", e.name, day, day, s)
+	return writeFile(filepath.Join(e.channelDir, threadsDir, ts+".md"), []byte(content))
+}
+
+func (e *exporter) renderIndex(pages []*dayPage) error {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "# Export of #%s\n\n", e.name)
+	if e.channel.Topic.Value != "" {
+		fmt.Fprintf(b, "Topic: %s\n\n", e.channel.Topic.Value)
+	}
+	if e.channel.Purpose.Value != "" {
+		fmt.Fprintf(b, "Purpose: %s\n\n", e.channel.Purpose.Value)
+	}
+	fmt.Fprintf(b, "Exported on %s.\n\n", time.Now().In(e.client.GetLocation()).Format("2006-01-02 15:04 MST"))
+
+	if len(pages) == 0 {
+		b.WriteString("There are no messages.\n")
+		return writeFile(filepath.Join(e.dir, indexFile), []byte(b.String()))
+	}
+
+	b.WriteString("| Day | Messages | Threads |\n")
+	b.WriteString("| --- | ---: | ---: |\n")
+	for _, page := range pages {
+		fmt.Fprintf(b, "| [%s](%s/%s.md) | %d | %d |\n", page.day, url.PathEscape(e.name), page.day, page.count, len(page.threads))
+	}
+
+	return writeFile(filepath.Join(e.dir, indexFile), []byte(b.String()))
+}
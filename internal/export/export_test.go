@@ -0,0 +1,281 @@
+package export
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rneatherway/gh-slack/internal/mocks"
+	"github.com/rneatherway/gh-slack/internal/slackclient"
+)
+
+// 1709596800 is 2024-03-05 00:00 UTC.
+var exportResponses = map[string]string{
+	"conversations.info":    `{"ok":true,"channel":{"id":"C1","name":"ops","is_channel":true,"created":1709000000,"topic":{"value":"Deploys"}}}`,
+	"conversations.members": `{"ok":true,"members":["U1","U2"]}`,
+	"users.list":            `{"ok":true,"members":[{"id":"U1","name":"alice"},{"id":"U2","name":"bob"}]}`,
+	"users.info?user=U1":    `{"ok":true,"user":{"id":"U1","name":"alice"}}`,
+	"users.info?user=U2":    `{"ok":true,"user":{"id":"U2","name":"bob"}}`,
+	"conversations.history": `{"ok":true,"messages":[
+		{"type":"message","user":"U2","text":"next day","ts":"1709683300.000001"},
+		{"type":"message","user":"U1","text":"deploying","ts":"1709596900.000001","thread_ts":"1709596900.000001","reply_count":1,"latest_reply":"1709683200.000002",
+			"files":[{"id":"F1","name":"log.txt","url_private_download":"https://files.slack.com/files-pri/T1-F1/download/log.txt"}]}
+	]}`,
+	"conversations.replies": `{"ok":true,"messages":[
+		{"type":"message","user":"U1","text":"deploying","ts":"1709596900.000001","thread_ts":"1709596900.000001","reply_count":1,"latest_reply":"1709683200.000002",
+			"files":[{"id":"F1","name":"log.txt","url_private_download":"https://files.slack.com/files-pri/T1-F1/download/log.txt"}]},
+		{"type":"message","user":"U2","text":"done","ts":"1709683200.000002","thread_ts":"1709596900.000001"}
+	]}`,
+	"log.txt": "deployed",
+}
+
+func exportClient(t *testing.T, responses map[string]string, calls *[]string) *slackclient.SlackClient {
+	mockClient := &mocks.MockClient{}
+	mockClient.MockResponses(responses, calls)
+	client, err := slackclient.Null("test", mockClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func readFile(t *testing.T, path ...string) string {
+	content, err := os.ReadFile(filepath.Join(path...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	err := Write(exportClient(t, exportResponses, nil), "C1", dir, Options{Files: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	days, err := Days(os.DirFS(dir), "ops")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(days, ",") != "2024-03-05,2024-03-06" {
+		t.Fatalf("unexpected days %v", days)
+	}
+
+	// Replies are in the day file of the day they were posted, as in Slack's
+	// own exports.
+	messages, err := ReadDay(os.DirFS(dir), "ops", "2024-03-06")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 2 || !strings.Contains(string(messages[0]), `"done"`) {
+		t.Errorf("unexpected messages on 2024-03-06: %s", messages)
+	}
+
+	var channels []Channel
+	err = json.Unmarshal([]byte(readFile(t, dir, channelsFile)), &channels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(channels) != 1 || channels[0].Name != "ops" || len(channels[0].Members) != 2 || channels[0].Topic.Value != "Deploys" {
+		t.Errorf("unexpected channels.json %+v", channels)
+	}
+
+	if readFile(t, dir, "ops", filesDir, "F1-log.txt") != "deployed" {
+		t.Error("expected the file to be downloaded")
+	}
+
+	day := readFile(t, dir, "ops", "2024-03-05.md")
+	for _, expected := range []string{"# #ops on 2024-03-05", "> deploying", "(threads/1709596900.000001.md), 1 replies", "[log.txt](files/F1-log.txt)"} {
+		if !strings.Contains(day, expected) {
+			t.Errorf("expected the day to contain %q:\n%s", expected, day)
+		}
+	}
+	if strings.Contains(day, "done") {
+		t.Errorf("expected the day not to contain replies:\n%s", day)
+	}
+
+	thread := readFile(t, dir, "ops", threadsDir, "1709596900.000001.md")
+	if !strings.Contains(thread, "> deploying") || !strings.Contains(thread, "> done") {
+		t.Errorf("unexpected thread:\n%s", thread)
+	}
+
+	index := readFile(t, dir, indexFile)
+	if !strings.Contains(index, "| [2024-03-06](ops/2024-03-06.md) | 2 | 0 |") {
+		t.Errorf("unexpected index:\n%s", index)
+	}
+}
+
+func TestWriteResumes(t *testing.T) {
+	dir := t.TempDir()
+	err := Write(exportClient(t, exportResponses, nil), "C1", dir, Options{Files: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resumed := map[string]string{}
+	for method, response := range exportResponses {
+		resumed[method] = response
+	}
+	resumed["conversations.history"] = `{"ok":true,"messages":[
+		{"type":"message","user":"U1","text":"later","ts":"1709683400.000001"},
+		{"type":"message","user":"U2","text":"next day","ts":"1709683300.000001"},
+		{"type":"message","user":"U1","text":"deploying","ts":"1709596900.000001","thread_ts":"1709596900.000001","reply_count":1,"latest_reply":"1709683200.000002",
+			"files":[{"id":"F1","name":"log.txt","url_private_download":"https://files.slack.com/files-pri/T1-F1/download/log.txt"}]}
+	]}`
+	delete(resumed, "log.txt")
+
+	var calls []string
+	err = Write(exportClient(t, resumed, &calls), "C1", dir, Options{Files: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, call := range calls {
+		if call == "conversations.replies" {
+			t.Error("expected the unchanged thread not to be fetched again")
+		}
+	}
+
+	messages, err := ReadDay(os.DirFS(dir), "ops", "2024-03-06")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 3 {
+		t.Errorf("expected the new message to be added, got %s", messages)
+	}
+
+	resumed["conversations.info"] = `{"ok":true,"channel":{"id":"C2","name":"ops"}}`
+	err = Write(exportClient(t, resumed, nil), "C2", dir, Options{})
+	if err == nil {
+		t.Error("expected an error exporting another channel to the same directory")
+	}
+}
+
+func TestWriteResumesThreadWithNewReplies(t *testing.T) {
+	dir := t.TempDir()
+	err := Write(exportClient(t, exportResponses, nil), "C1", dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resumed := map[string]string{}
+	for method, response := range exportResponses {
+		resumed[method] = response
+	}
+	// The whole history is fetched again, as the thread's root is older than
+	// anything new.
+	delete(resumed, "conversations.history")
+	resumed["conversations.history?channel=C1&cursor=&latest=&limit=200"] = `{"ok":true,"messages":[
+		{"type":"message","user":"U2","text":"next day","ts":"1709683300.000001"},
+		{"type":"message","user":"U1","text":"deploying","ts":"1709596900.000001","thread_ts":"1709596900.000001","reply_count":2,"latest_reply":"1709683500.000001"}
+	]}`
+	resumed["conversations.replies"] = `{"ok":true,"messages":[
+		{"type":"message","user":"U1","text":"deploying","ts":"1709596900.000001","thread_ts":"1709596900.000001","reply_count":2,"latest_reply":"1709683500.000001"},
+		{"type":"message","user":"U2","text":"done","ts":"1709683200.000002","thread_ts":"1709596900.000001"},
+		{"type":"message","user":"U1","text":"reopened","ts":"1709683500.000001","thread_ts":"1709596900.000001"}
+	]}`
+
+	err = Write(exportClient(t, resumed, nil), "C1", dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages, err := ReadDay(os.DirFS(dir), "ops", "2024-03-06")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 3 || !strings.Contains(string(messages[2]), `"reopened"`) {
+		t.Errorf("expected the new reply to be exported, got %s", messages)
+	}
+
+	thread := readFile(t, dir, "ops", threadsDir, "1709596900.000001.md")
+	if !strings.Contains(thread, "reopened") {
+		t.Errorf("expected the thread to be rendered with the new reply:\n%s", thread)
+	}
+}
+
+func TestWriteResumesInterruptedHistory(t *testing.T) {
+	firstPage := `{"ok":true,"messages":[
+		{"type":"message","user":"U2","text":"next day","ts":"1709683300.000001"}
+	],"response_metadata":{"next_cursor":"p2"}}`
+	secondPage := `{"ok":true,"messages":[
+		{"type":"message","user":"U1","text":"deploying","ts":"1709596900.000001","thread_ts":"1709596900.000001","reply_count":1,"latest_reply":"1709683200.000002"}
+	]}`
+
+	for name, resumedPages := range map[string]map[string]string{
+		"from the cursor": {
+			"conversations.history?channel=C1&cursor=p2&latest=&limit=200": secondPage,
+		},
+		"from the oldest message once the cursor has expired": {
+			"conversations.history?channel=C1&cursor=p2&latest=&limit=200":                `{"ok":false,"error":"invalid_cursor"}`,
+			"conversations.history?channel=C1&cursor=&latest=1709683300.000001&limit=200": secondPage,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			interrupted := map[string]string{}
+			for method, response := range exportResponses {
+				interrupted[method] = response
+			}
+			delete(interrupted, "conversations.history")
+			// The second page fails to be fetched.
+			interrupted["conversations.history?channel=C1&cursor=&latest=&limit=200"] = firstPage
+
+			dir := t.TempDir()
+			err := Write(exportClient(t, interrupted, nil), "C1", dir, Options{})
+			if err == nil {
+				t.Fatal("expected the export to be interrupted")
+			}
+
+			var s state
+			err = json.Unmarshal([]byte(readFile(t, dir, stateFile)), &s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.History == nil || s.History.Cursor != "p2" || s.History.Oldest != "1709683300.000001" {
+				t.Fatalf("expected the history's progress to be saved, got %+v", s.History)
+			}
+
+			// The first page isn't fetched again.
+			resumed := map[string]string{}
+			for method, response := range exportResponses {
+				resumed[method] = response
+			}
+			delete(resumed, "conversations.history")
+			for key, response := range resumedPages {
+				resumed[key] = response
+			}
+
+			err = Write(exportClient(t, resumed, nil), "C1", dir, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, day := range []string{"2024-03-05", "2024-03-06"} {
+				messages, err := ReadDay(os.DirFS(dir), "ops", day)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(messages) == 0 {
+					t.Errorf("expected messages on %s", day)
+				}
+			}
+
+			thread := readFile(t, dir, "ops", threadsDir, "1709596900.000001.md")
+			if !strings.Contains(thread, "done") {
+				t.Errorf("expected the thread to be exported once the history was complete:\n%s", thread)
+			}
+
+			s = state{}
+			err = json.Unmarshal([]byte(readFile(t, dir, stateFile)), &s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.History != nil {
+				t.Errorf("expected the completed history not to be saved, got %+v", s.History)
+			}
+		})
+	}
+}
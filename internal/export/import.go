@@ -0,0 +1,209 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+
+	"github.com/rneatherway/gh-slack/internal/slackclient"
+)
+
+// conversationList is a file in an export listing conversations of one kind,
+// each of which has a directory of day files.
+type conversationList struct {
+	file    string
+	private bool
+	mpim    bool
+	// dm conversations are named after their ID, and are not cached, as the
+	// other member can't be told apart from the user who exported them.
+	dm bool
+}
+
+var conversationLists = []conversationList{
+	{file: channelsFile},
+	{file: "groups.json", private: true},
+	{file: "mpims.json", private: true, mpim: true},
+	{file: "dms.json", dm: true},
+}
+
+// Summary counts what was imported.
+type Summary struct {
+	Users    int
+	Channels int
+	Messages int
+}
+
+// Import reads an export in the layout of Slack's workspace exports, either
+// Slack's own or one written by Write, into the client's cache and message
+// archive. Its conversations can then be read offline, with no access to the
+// Slack API.
+func Import(client *slackclient.SlackClient, fsys fs.FS, progress io.Writer) (Summary, error) {
+	var summary Summary
+
+	// The conversation IDs name directories in the archive, so are all checked
+	// before anything is imported.
+	conversations := make([][]Channel, len(conversationLists))
+	found := false
+	for i, list := range conversationLists {
+		ok, err := readJSON(fsys, list.file, &conversations[i])
+		if err != nil {
+			return summary, err
+		}
+		found = found || ok
+
+		for _, channel := range conversations[i] {
+			if !slackclient.ValidConversationID(channel.ID) {
+				return summary, fmt.Errorf("invalid conversation ID %q in %s", channel.ID, list.file)
+			}
+		}
+	}
+	if !found {
+		return summary, errors.New("no conversations found, expected channels.json or another list of conversations")
+	}
+
+	var users []User
+	ok, err := readJSON(fsys, usersFile, &users)
+	if err != nil {
+		return summary, err
+	}
+	if ok {
+		imported := make([]slackclient.User, len(users))
+		for i, user := range users {
+			imported[i] = slackclient.User{
+				ID:       user.ID,
+				Name:     user.Name,
+				RealName: user.RealName,
+				TZ:       user.TZ,
+				Deleted:  user.Deleted,
+				IsBot:    user.IsBot,
+				Profile:  user.Profile,
+			}
+		}
+
+		err = client.ImportUsers(imported)
+		if err != nil {
+			return summary, err
+		}
+		summary.Users = len(users)
+	}
+
+	for i, list := range conversationLists {
+		var cached []slackclient.Channel
+		for _, channel := range conversations[i] {
+			dir := channel.Name
+			if list.dm {
+				dir = channel.ID
+			} else {
+				cached = append(cached, slackclient.Channel{
+					ID:          channel.ID,
+					Name:        channel.Name,
+					Is_Channel:  !list.mpim,
+					Is_Mpim:     list.mpim,
+					Is_Private:  list.private,
+					Is_Archived: channel.IsArchived,
+					Topic:       slackclient.ChannelText{Value: channel.Topic.Value},
+					Purpose:     slackclient.ChannelText{Value: channel.Purpose.Value},
+					NumMembers:  len(channel.Members),
+					Created:     channel.Created,
+					Creator:     channel.Creator,
+				})
+			}
+
+			count, err := importMessages(client, fsys, dir, channel.ID)
+			if err != nil {
+				return summary, err
+			}
+			if progress != nil {
+				fmt.Fprintf(progress, "Imported %d messages from %s\n", count, dir)
+			}
+			summary.Messages += count
+			summary.Channels++
+		}
+
+		err = client.ImportChannels(cached)
+		if err != nil {
+			return summary, err
+		}
+	}
+
+	return summary, nil
+}
+
+// importMessages imports the history of a conversation, and each of its
+// threads, from its day files. Thread timestamps name files in the archive, so
+// are checked before any of the conversation is imported.
+func importMessages(client *slackclient.SlackClient, fsys fs.FS, dir, channelID string) (int, error) {
+	days, err := Days(fsys, dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	var history []slackclient.Message
+	threads := map[string][]slackclient.Message{}
+	count := 0
+	for _, day := range days {
+		messages, err := ReadDay(fsys, dir, day)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, raw := range messages {
+			f, err := parseFields(raw)
+			if err != nil {
+				return 0, err
+			}
+
+			var message slackclient.Message
+			err = json.Unmarshal(raw, &message)
+			if err != nil {
+				return 0, err
+			}
+
+			if f.inChannel() {
+				history = append(history, message)
+			}
+			if f.ThreadTs != "" {
+				if !slackclient.ValidTimestamp(f.ThreadTs) {
+					return 0, fmt.Errorf("invalid thread timestamp %q in %s", f.ThreadTs, path.Join(dir, day+".json"))
+				}
+				threads[f.ThreadTs] = append(threads[f.ThreadTs], message)
+			}
+			count++
+		}
+	}
+
+	err = client.ImportHistory(channelID, "", history)
+	if err != nil {
+		return 0, err
+	}
+
+	for thread, messages := range threads {
+		err = client.ImportHistory(channelID, thread, messages)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return count, nil
+}
+
+// readJSON reads a JSON file from an export, reporting whether it exists.
+func readJSON(fsys fs.FS, name string, v any) (bool, error) {
+	content, err := fs.ReadFile(fsys, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	err = json.Unmarshal(content, v)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,190 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/rneatherway/gh-slack/internal/slackclient"
+)
+
+// slackExport is laid out like a workspace export downloaded from Slack.
+var slackExport = fstest.MapFS{
+	"users.json": {Data: []byte(`[
+		{"id":"U1","name":"alice","real_name":"Alice","profile":{"display_name":"ally"}},
+		{"id":"U2","name":"bob","deleted":true}
+	]`)},
+	"channels.json": {Data: []byte(`[
+		{"id":"C1","name":"ops","created":1709000000,"creator":"U1","members":["U1","U2"],"topic":{"value":"Deploys"}}
+	]`)},
+	"groups.json": {Data: []byte(`[{"id":"G1","name":"secret","members":["U1"]}]`)},
+	"dms.json":    {Data: []byte(`[{"id":"D1","members":["U1","U2"]}]`)},
+	"ops/2024-03-05.json": {Data: []byte(`[
+		{"type":"message","user":"U1","text":"deploying","ts":"1709596900.000001","thread_ts":"1709596900.000001","reply_count":1},
+		{"type":"message","user":"U2","text":"good luck","ts":"1709597000.000001"}
+	]`)},
+	"ops/2024-03-06.json": {Data: []byte(`[
+		{"type":"message","user":"U2","text":"done","ts":"1709683200.000002","thread_ts":"1709596900.000001"},
+		{"type":"message","user":"U2","text":"next day","ts":"1709683300.000001"}
+	]`)},
+	"secret/2024-03-05.json": {Data: []byte(`[{"type":"message","user":"U1","text":"psst","ts":"1709596950.000001"}]`)},
+	"D1/2024-03-05.json":     {Data: []byte(`[{"type":"message","user":"U2","text":"hi","ts":"1709596960.000001"}]`)},
+}
+
+func TestImport(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", filepath.Join(t.TempDir(), "data"))
+
+	client, err := slackclient.NewOffline("test", log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	summary, err := Import(client, slackExport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary != (Summary{Users: 2, Channels: 3, Messages: 6}) {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+
+	client.UseArchive()
+
+	for id, want := range map[string]string{"C1": "#ops", "G1": "#secret"} {
+		name, err := client.ConversationName(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != want {
+			t.Errorf("expected %s to be named %q, got %q", id, want, name)
+		}
+	}
+
+	user, err := client.UserForID("U1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Name != "alice" || user.Profile.DisplayName != "ally" {
+		t.Errorf("unexpected user %+v", user)
+	}
+
+	// Replies are only in the thread, which starting at its root reads.
+	history, err := client.History("C1", "1709597000.000001", "", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history.Messages) != 2 || history.Messages[1].Text != "next day" {
+		t.Errorf("unexpected history %+v", history.Messages)
+	}
+
+	thread, err := client.History("C1", "1709596900.000001", "", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(thread.Messages) != 2 || thread.Messages[1].Text != "done" {
+		t.Errorf("unexpected thread %+v", thread.Messages)
+	}
+
+	dm, err := client.History("D1", "1709596960.000001", "", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dm.Messages) != 1 {
+		t.Errorf("unexpected direct messages %+v", dm.Messages)
+	}
+}
+
+func TestImportWithoutConversations(t *testing.T) {
+	client := exportClient(t, nil, nil)
+	_, err := Import(client, fstest.MapFS{"users.json": {Data: []byte(`[]`)}}, nil)
+	if err == nil {
+		t.Fatal("expected an error for an export without conversations")
+	}
+}
+
+// zipFS builds a ZIP file in memory from the given files.
+func zipFS(t *testing.T, files map[string]string) fs.FS {
+	var b bytes.Buffer
+	w := zip.NewWriter(&b)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = f.Write([]byte(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestImportRejectsPathTraversal(t *testing.T) {
+	tests := map[string]map[string]string{
+		"conversation ID": {
+			"channels.json":       `[{"id":"C1","name":"ops"},{"id":"../../../../escaped","name":"evil"}]`,
+			"ops/2024-03-05.json": `[{"type":"message","user":"U1","text":"hi","ts":"1709596900.000001"}]`,
+		},
+		"thread timestamp": {
+			"channels.json": `[{"id":"C1","name":"ops"}]`,
+			"ops/2024-03-05.json": `[
+				{"type":"message","user":"U1","text":"hi","ts":"1709596900.000001"},
+				{"type":"message","user":"U1","text":"reply","ts":"1709596901.000001","thread_ts":"../../../../../escaped"}
+			]`,
+		},
+	}
+
+	for name, files := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			dataHome := filepath.Join(dir, "a", "b", "c", "data")
+			t.Setenv("XDG_DATA_HOME", dataHome)
+
+			client, err := slackclient.NewOffline("test", log.New(io.Discard, "", 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+
+			_, err = Import(client, zipFS(t, files), nil)
+			if err == nil {
+				t.Fatal("expected the import to be rejected")
+			}
+
+			err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if d.Name() == "escaped" || d.Name() == "escaped.json" {
+					return errors.New("wrote " + p)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+
+			// Nothing is archived from a rejected conversation.
+			_, err = os.Stat(filepath.Join(dataHome, "gh-slack", "teams", "test", "archive", "C1"))
+			if !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("expected nothing to be archived, got %v", err)
+			}
+		})
+	}
+}
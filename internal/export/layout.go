@@ -0,0 +1,235 @@
+// Package export writes and reads channel archives in the layout of Slack's
+// official workspace export:
+//
+//	channels.json
+//	users.json
+//	<channel>/<YYYY-MM-DD>.json
+//
+// where each day's file holds every message, including thread replies,
+// posted that day (UTC), oldest first. Exports written by gh-slack add
+// rendered markdown, downloaded files and an index alongside:
+//
+//	index.md
+//	<channel>/<YYYY-MM-DD>.md
+//	<channel>/threads/<thread ts>.md
+//	<channel>/files/<file id>-<name>
+package export
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rneatherway/gh-slack/internal/slackclient"
+)
+
+const (
+	channelsFile = "channels.json"
+	usersFile    = "users.json"
+	indexFile    = "index.md"
+	threadsDir   = "threads"
+	filesDir     = "files"
+	dayLayout    = "2006-01-02"
+)
+
+// Text is a channel's topic or purpose.
+type Text struct {
+	Value   string `json:"value"`
+	Creator string `json:"creator"`
+	LastSet int64  `json:"last_set"`
+}
+
+// Channel is an entry in channels.json.
+type Channel struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Created    int64    `json:"created"`
+	Creator    string   `json:"creator"`
+	IsArchived bool     `json:"is_archived"`
+	IsGeneral  bool     `json:"is_general"`
+	Members    []string `json:"members"`
+	Topic      Text     `json:"topic"`
+	Purpose    Text     `json:"purpose"`
+}
+
+// User is an entry in users.json.
+type User struct {
+	ID       string                  `json:"id"`
+	Name     string                  `json:"name"`
+	Deleted  bool                    `json:"deleted"`
+	RealName string                  `json:"real_name,omitempty"`
+	TZ       string                  `json:"tz,omitempty"`
+	IsBot    bool                    `json:"is_bot"`
+	Profile  slackclient.UserProfile `json:"profile"`
+}
+
+// File is a file shared in a message.
+type File struct {
+	ID                 string `json:"id"`
+	Name               string `json:"name"`
+	Title              string `json:"title"`
+	Mode               string `json:"mode"`
+	URLPrivate         string `json:"url_private"`
+	URLPrivateDownload string `json:"url_private_download"`
+}
+
+// fields are the parts of a message needed to organise an export. The rest is
+// kept as Slack returned it.
+type fields struct {
+	Ts          string `json:"ts"`
+	ThreadTs    string `json:"thread_ts"`
+	ReplyCount  int    `json:"reply_count"`
+	LatestReply string `json:"latest_reply"`
+	Subtype     string `json:"subtype"`
+	Files       []File `json:"files"`
+}
+
+func parseFields(raw json.RawMessage) (fields, error) {
+	var f fields
+	err := json.Unmarshal(raw, &f)
+	if err != nil {
+		return f, err
+	}
+	if f.Ts == "" {
+		return f, fmt.Errorf("message without a timestamp: %s", raw)
+	}
+	return f, nil
+}
+
+// isReply reports whether a message is a reply in a thread, rather than in the
+// channel itself. Replies also sent to the channel count as both.
+func (f fields) isReply() bool {
+	return f.ThreadTs != "" && f.ThreadTs != f.Ts
+}
+
+func (f fields) inChannel() bool {
+	return !f.isReply() || f.Subtype == "thread_broadcast"
+}
+
+// downloadable reports whether a file's content can be downloaded, rather
+// than being deleted, hidden by the workspace's plan or hosted elsewhere.
+func (f File) downloadable() bool {
+	return f.URLPrivateDownload != "" && f.Mode != "tombstone" && f.Mode != "hidden_by_limit" && f.Mode != "external"
+}
+
+// localName is the name of a downloaded file in the files directory.
+func (f File) localName() string {
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r < ' ' {
+			return '_'
+		}
+		return r
+	}, f.Name)
+	if name == "" || name == "." || name == ".." {
+		return f.ID
+	}
+	return f.ID + "-" + name
+}
+
+// timestampTime converts a Slack timestamp to a time.
+func timestampTime(ts string) (time.Time, error) {
+	seconds, micros, _ := strings.Cut(ts, ".")
+	s, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q", ts)
+	}
+	us := int64(0)
+	if micros != "" {
+		us, err = strconv.ParseInt(micros, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp %q", ts)
+		}
+	}
+	return time.Unix(s, us*1000).UTC(), nil
+}
+
+// dayOf returns the day (UTC) of a timestamp, which names its day file.
+func dayOf(ts string) (string, error) {
+	t, err := timestampTime(ts)
+	if err != nil {
+		return "", err
+	}
+	return t.Format(dayLayout), nil
+}
+
+// compareTimestamps orders Slack timestamps.
+func compareTimestamps(a, b string) int {
+	aSeconds, aMicros, _ := strings.Cut(a, ".")
+	bSeconds, bMicros, _ := strings.Cut(b, ".")
+	return cmp.Or(
+		cmp.Compare(len(aSeconds), len(bSeconds)),
+		strings.Compare(aSeconds, bSeconds),
+		strings.Compare(aMicros, bMicros))
+}
+
+// Days returns the days of a channel in an export that have a day file,
+// oldest first.
+func Days(fsys fs.FS, channel string) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	var days []string
+	for _, entry := range entries {
+		day, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if _, err := time.Parse(dayLayout, day); err == nil {
+			days = append(days, day)
+		}
+	}
+
+	slices.Sort(days)
+	return days, nil
+}
+
+// ReadDay returns the messages in a channel's day file, oldest first, as they
+// were returned by Slack.
+func ReadDay(fsys fs.FS, channel, day string) ([]json.RawMessage, error) {
+	name := path.Join(channel, day+".json")
+	content, err := fs.ReadFile(fsys, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var messages []json.RawMessage
+	err = json.Unmarshal(content, &messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return messages, nil
+}
+
+// writeJSON writes v as indented JSON, by renaming a temporary file over the
+// destination so that an interrupted export never leaves a partial file.
+func writeJSON(filePath string, v any) error {
+	content, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return err
+	}
+	return writeFile(filePath, content)
+}
+
+// writeFile writes a file with slackclient.WriteFileAtomic, creating its
+// directory first if needed.
+func writeFile(filePath string, content []byte) error {
+	err := os.MkdirAll(filepath.Dir(filePath), 0755)
+	if err != nil {
+		return err
+	}
+
+	return slackclient.WriteFileAtomic(filePath, content)
+}
@@ -1,20 +1,21 @@
 package gh
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 
 	"github.com/cli/go-gh/v2"
 )
 
-func NewIssue(repoUrl string, channelName, content string) error {
+func NewIssue(repoUrl string, conversationName, content string) error {
 	out, _, err := gh.Exec(
 		"issue",
 		"-R",
 		repoUrl,
 		"create",
 		"--title",
-		fmt.Sprintf("Slack conversation archive of `#%s`", channelName),
+		fmt.Sprintf("Slack conversation archive of `%s`", conversationName),
 		"--body",
 		content)
 	os.Stdout.Write(out.Bytes())
@@ -31,3 +32,67 @@ func AddComment(subCmd, url, content string) error {
 	os.Stdout.Write(out.Bytes())
 	return err
 }
+
+const orgMembersQuery = `query($org: String!, $endCursor: String) {
+  organization(login: $org) {
+    membersWithRole(first: 100, after: $endCursor) {
+      nodes {
+        login
+        email
+        organizationVerifiedDomainEmails(login: $org)
+      }
+      pageInfo {
+        hasNextPage
+        endCursor
+      }
+    }
+  }
+}`
+
+// OrgMemberEmails returns the login of each member of a GitHub organization,
+// keyed by their public email and any emails in the organization's verified
+// domains.
+func OrgMemberEmails(org string) (map[string]string, error) {
+	out, _, err := gh.Exec(
+		"api",
+		"graphql",
+		"--paginate",
+		"-f", "query="+orgMembersQuery,
+		"-F", "org="+org)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members of %s: %w", org, err)
+	}
+
+	emails := map[string]string{}
+	decoder := json.NewDecoder(&out)
+	for decoder.More() {
+		var page struct {
+			Data struct {
+				Organization struct {
+					MembersWithRole struct {
+						Nodes []struct {
+							Login                            string
+							Email                            string
+							OrganizationVerifiedDomainEmails []string
+						}
+					}
+				}
+			}
+		}
+		err = decoder.Decode(&page)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, member := range page.Data.Organization.MembersWithRole.Nodes {
+			if member.Email != "" {
+				emails[member.Email] = member.Login
+			}
+			for _, email := range member.OrganizationVerifiedDomainEmails {
+				emails[email] = member.Login
+			}
+		}
+	}
+
+	return emails, nil
+}
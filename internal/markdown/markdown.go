@@ -7,11 +7,21 @@ import (
 	"time"
 
 	"github.com/rneatherway/gh-slack/internal/slackclient"
+	"github.com/rneatherway/gh-slack/internal/usermap"
 	"github.com/rneatherway/slack/pkg/markdown"
 )
 
+// mentionNames names mentioned users in the same style as message authors.
+type mentionNames struct {
+	client *slackclient.SlackClient
+}
+
+func (m mentionNames) UsernameForID(id string) (string, error) {
+	return m.client.NameForID(id)
+}
+
 func convert(client *slackclient.SlackClient, b *strings.Builder, s string) error {
-	text, err := markdown.Convert(client, s)
+	text, err := markdown.Convert(mentionNames{client}, s)
 	if err != nil {
 		return err
 	}
@@ -24,49 +34,160 @@ func convert(client *slackclient.SlackClient, b *strings.Builder, s string) erro
 	return nil
 }
 
+// Options control how messages are rendered by FromMessagesWithOptions.
+type Options struct {
+	// GitHubUsers, if set, is used to name message authors by their GitHub
+	// login as well as their Slack name.
+	GitHubUsers *usermap.Map
+	// Mention @-mentions those authors on GitHub, rather than only naming them.
+	Mention bool
+}
+
 func FromMessages(client *slackclient.SlackClient, history *slackclient.HistoryResponse) (string, error) {
+	return FromMessagesWithOptions(client, history, Options{})
+}
+
+// author returns the author of a message, in bold, for the header line. This
+// is their GitHub login followed by their Slack name if they are mapped.
+func author(client *slackclient.SlackClient, message slackclient.Message, opts Options) (string, error) {
+	username, err := client.UsernameForMessage(message)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.GitHubUsers == nil || message.User == "" {
+		return fmt.Sprintf("**%s**", username), nil
+	}
+
+	user, err := client.UserForID(message.User)
+	if err != nil {
+		return "", err
+	}
+
+	login, ok := opts.GitHubUsers.Login(user)
+	if !ok {
+		return fmt.Sprintf("**%s**", username), nil
+	}
+
+	if !opts.Mention {
+		// A zero-width space stops GitHub treating this as a mention.
+		login = "&#8203;" + login
+	}
+
+	return fmt.Sprintf("**@%s** (slack: %s)", login, username), nil
+}
+
+func FromMessagesWithOptions(client *slackclient.SlackClient, history *slackclient.HistoryResponse, opts Options) (string, error) {
+	entries := make([]entry, 0, len(history.Messages))
+	for _, message := range history.Messages {
+		entries = append(entries, entry{client: client, message: message})
+	}
+
 	b := &strings.Builder{}
-	messages := history.Messages
-	msgTimes := make(map[string]time.Time, len(messages))
+	err := render(b, entries, opts)
+	if err != nil {
+		return "", err
+	}
 
-	for _, message := range messages {
-		tm, err := markdown.ParseUnixTimestamp(message.Ts)
+	return b.String(), nil
+}
+
+// Conversation is the history read from one permalink.
+type Conversation struct {
+	Client *slackclient.SlackClient
+	// Name is the conversation name, as returned by
+	// SlackClient.ConversationName.
+	Name    string
+	Link    string
+	History *slackclient.HistoryResponse
+}
+
+// FromConversations renders several conversations as one document. Each is
+// given its own section unless merge is set, in which case their messages
+// are interleaved in time order and each header names its conversation.
+func FromConversations(conversations []Conversation, merge bool, opts Options) (string, error) {
+	b := &strings.Builder{}
+
+	if merge {
+		var entries []entry
+		for _, c := range conversations {
+			for _, message := range c.History.Messages {
+				entries = append(entries, entry{client: c.Client, message: message, label: c.Name})
+			}
+		}
+
+		err := render(b, entries, opts)
 		if err != nil {
 			return "", err
 		}
 
-		msgTimes[message.Ts] = *tm
+		return b.String(), nil
+	}
+
+	for i, c := range conversations {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(b, "### [`%s`](%s)\n\n", c.Name, c.Link)
+
+		s, err := FromMessagesWithOptions(c.Client, c.History, opts)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(strings.TrimRight(s, "\n") + "\n")
+	}
+
+	return b.String(), nil
+}
+
+// entry is a message to render, with the client that can name its author and
+// the conversation it was posted in when several are being merged.
+type entry struct {
+	client  *slackclient.SlackClient
+	message slackclient.Message
+	label   string
+}
+
+func render(b *strings.Builder, entries []entry, opts Options) error {
+	msgTimes := make([]time.Time, len(entries))
+	for i, e := range entries {
+		tm, err := markdown.ParseUnixTimestamp(e.message.Ts)
+		if err != nil {
+			return err
+		}
+
+		msgTimes[i] = *tm
 	}
 
 	// It's surprising that these messages are not already always returned in date order,
 	// and actually I observed initially that they seemed to be, but at least some of the
 	// time they are returned in reverse order so it's simpler to just sort them now.
-	sort.Slice(messages, func(i, j int) bool {
-		return msgTimes[messages[i].Ts].Before(msgTimes[messages[j].Ts])
-	})
+	sort.Stable(byTime{entries, msgTimes})
 
 	lastSpeakerID := ""
 
-	for i, message := range messages {
-		username, err := client.UsernameForMessage(message)
+	for i, e := range entries {
+		client, message := e.client, e.message
+		speaker, err := author(client, message, opts)
 		if err != nil {
-			return "", err
+			return err
 		}
 
 		speakerID := message.User
 		if speakerID == "" {
 			speakerID = message.BotID
 		}
+		// The same person in another conversation starts a new block.
+		speakerID = e.label + "/" + speakerID
 
-		messageTime := msgTimes[message.Ts]
+		messageTime := msgTimes[i]
 		messageTimeDiffInMinutes := 0
 
 		// How far apart in minutes can two messages be, by the same author, before we repeat the header line?
 		messageTimeMinuteCutoff := 60
 
 		if i > 0 {
-			prevMessage := messages[i-1]
-			prevMessageTime := msgTimes[prevMessage.Ts]
+			prevMessageTime := msgTimes[i-1]
 			messageTimeDiffInMinutes = int(messageTime.Sub(prevMessageTime).Minutes())
 		}
 
@@ -78,8 +199,11 @@ func FromMessages(client *slackclient.SlackClient, history *slackclient.HistoryR
 			messageTimeDiffInMinutes > messageTimeMinuteCutoff
 
 		if includeSpeakerHeader {
-			fmt.Fprintf(b, "> **%s** at %s\n",
-				username,
+			if e.label != "" {
+				speaker = fmt.Sprintf("%s in `%s`", speaker, e.label)
+			}
+			fmt.Fprintf(b, "> %s at %s\n",
+				speaker,
 				messageTime.In(client.GetLocation()).Format("2006-01-02 15:04 MST"))
 		}
 		fmt.Fprintf(b, ">\n")
@@ -87,7 +211,7 @@ func FromMessages(client *slackclient.SlackClient, history *slackclient.HistoryR
 		if message.Text != "" {
 			err = convert(client, b, message.Text)
 			if err != nil {
-				return "", err
+				return err
 			}
 		}
 
@@ -95,7 +219,7 @@ func FromMessages(client *slackclient.SlackClient, history *slackclient.HistoryR
 		for _, a := range message.Attachments {
 			err = convert(client, b, a.Text)
 			if err != nil {
-				return "", err
+				return err
 			}
 		}
 
@@ -106,10 +230,38 @@ func FromMessages(client *slackclient.SlackClient, history *slackclient.HistoryR
 		lastSpeakerID = speakerID
 	}
 
-	return b.String(), nil
+	return nil
 }
 
-func WrapInDetails(channelName, link, s string) string {
-	return fmt.Sprintf("Slack conversation archive of [`#%s`](%s)\n\n<details>\n  <summary>Click to expand</summary>\n\n%s\n</details>",
-		channelName, link, s)
+// byTime sorts entries alongside their parsed times. The sort is stable so
+// that messages sent in the same microsecond keep their order.
+type byTime struct {
+	entries []entry
+	times   []time.Time
+}
+
+func (s byTime) Len() int           { return len(s.entries) }
+func (s byTime) Less(i, j int) bool { return s.times[i].Before(s.times[j]) }
+func (s byTime) Swap(i, j int) {
+	s.entries[i], s.entries[j] = s.entries[j], s.entries[i]
+	s.times[i], s.times[j] = s.times[j], s.times[i]
+}
+
+// WrapInDetails wraps s in a collapsed <details> block titled with the
+// conversation name, as returned by SlackClient.ConversationName.
+func WrapInDetails(conversationName, link, s string) string {
+	return fmt.Sprintf("Slack conversation archive of [`%s`](%s)\n\n<details>\n  <summary>Click to expand</summary>\n\n%s\n</details>",
+		conversationName, link, s)
+}
+
+// WrapConversationsInDetails is WrapInDetails for a document made from
+// several conversations, titled with each of them.
+func WrapConversationsInDetails(conversations []Conversation, s string) string {
+	links := make([]string, len(conversations))
+	for i, c := range conversations {
+		links[i] = fmt.Sprintf("[`%s`](%s)", c.Name, c.Link)
+	}
+
+	return fmt.Sprintf("Slack conversation archive of %s\n\n<details>\n  <summary>Click to expand</summary>\n\n%s\n</details>",
+		strings.Join(links, ", "), s)
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/rneatherway/gh-slack/internal/mocks"
 	"github.com/rneatherway/gh-slack/internal/slackclient"
+	"github.com/rneatherway/gh-slack/internal/usermap"
 )
 
 func TestFromMessagesCombinesAdjacentMessagesFromSameUser(t *testing.T) {
@@ -126,3 +127,140 @@ func TestFromMessagesSeparatesMessagesFromSameUserWhenNotAdjacent(t *testing.T)
 		t.Fatal("expected:\n\n", expected, "\n\ngot:\n\n", actual)
 	}
 }
+
+func TestFromMessagesUsesNameStyle(t *testing.T) {
+	mockClient := &mocks.MockClient{}
+	mockClient.MockSuccessfulAuthResponse()
+	client, err := slackclient.Null("test", mockClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.WithNameStyle(slackclient.NameStyleBoth)
+	messages := []slackclient.Message{
+		{Text: "thanks <@1234>", User: "82317", BotID: "", Ts: "123.456"},
+	}
+	history := &slackclient.HistoryResponse{Ok: true, HasMore: false, Messages: messages}
+	mockClient.MockSuccessfulUsersResponse([]slackclient.User{
+		{ID: "82317", Name: "cheshire137", RealName: "Sarah Vessels"},
+		{ID: "1234", Name: "octokatherine", RealName: "Katherine Oelsner"},
+	})
+	actual, err := FromMessages(client, history)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "> **Sarah Vessels (cheshire137)** at 1970-01-01 00:02 UTC\n" +
+		">\n" +
+		"> thanks `@Katherine Oelsner (octokatherine)`"
+	if expected != strings.TrimSpace(actual) {
+		t.Fatal("expected:\n\n", expected, "\n\ngot:\n\n", actual)
+	}
+}
+
+func TestFromMessagesNamesGitHubUsers(t *testing.T) {
+	mockClient := &mocks.MockClient{}
+	client, err := slackclient.Null("test", mockClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := []slackclient.Message{
+		{Text: "hello", User: "82317", Ts: "123.456"},
+		{Text: "hi", User: "1234", Ts: "124.567"},
+	}
+	history := &slackclient.HistoryResponse{Ok: true, HasMore: false, Messages: messages}
+	mockClient.MockSuccessfulUsersResponse([]slackclient.User{
+		{ID: "82317", Name: "jdoe"},
+		{ID: "1234", Name: "unmapped"},
+	})
+	githubUsers := usermap.New()
+	githubUsers.Add("jdoe", "octocat")
+
+	for _, tt := range []struct {
+		mention  bool
+		expected string
+	}{
+		{false, "**@&#8203;octocat** (slack: jdoe)"},
+		{true, "**@octocat** (slack: jdoe)"},
+	} {
+		actual, err := FromMessagesWithOptions(client, history, Options{GitHubUsers: githubUsers, Mention: tt.mention})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := "> " + tt.expected + " at 1970-01-01 00:02 UTC\n" +
+			">\n" +
+			"> hello\n\n" +
+			"> **unmapped** at 1970-01-01 00:02 UTC\n" +
+			">\n" +
+			"> hi"
+		if expected != strings.TrimSpace(actual) {
+			t.Fatal("expected:\n\n", expected, "\n\ngot:\n\n", actual)
+		}
+	}
+}
+
+func twoConversations(t *testing.T) []Conversation {
+	mockClient := &mocks.MockClient{}
+	client, err := slackclient.Null("test", mockClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockClient.MockSuccessfulUsersResponse([]slackclient.User{{ID: "82317", Name: "cheshire137"}})
+
+	return []Conversation{
+		{
+			Client: client,
+			Name:   "#ops",
+			Link:   "https://example.slack.com/archives/C0001/p123456000",
+			History: &slackclient.HistoryResponse{Ok: true, Messages: []slackclient.Message{
+				{Text: "first", User: "82317", Ts: "123.456"},
+				{Text: "third", User: "82317", Ts: "125.567"},
+			}},
+		},
+		{
+			Client: client,
+			Name:   "#dev",
+			Link:   "https://example.slack.com/archives/C0002/p124456000",
+			History: &slackclient.HistoryResponse{Ok: true, Messages: []slackclient.Message{
+				{Text: "second", User: "82317", Ts: "124.456"},
+			}},
+		},
+	}
+}
+
+func TestFromConversationsInSections(t *testing.T) {
+	actual, err := FromConversations(twoConversations(t), false, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "### [`#ops`](https://example.slack.com/archives/C0001/p123456000)\n\n" +
+		"> **cheshire137** at 1970-01-01 00:02 UTC\n" +
+		">\n" +
+		"> first\n" +
+		">\n" +
+		"> third\n\n" +
+		"### [`#dev`](https://example.slack.com/archives/C0002/p124456000)\n\n" +
+		"> **cheshire137** at 1970-01-01 00:02 UTC\n" +
+		">\n" +
+		"> second"
+	if expected != strings.TrimSpace(actual) {
+		t.Fatal("expected:\n\n", expected, "\n\ngot:\n\n", actual)
+	}
+}
+
+func TestFromConversationsMerged(t *testing.T) {
+	actual, err := FromConversations(twoConversations(t), true, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "> **cheshire137** in `#ops` at 1970-01-01 00:02 UTC\n" +
+		">\n" +
+		"> first\n\n" +
+		"> **cheshire137** in `#dev` at 1970-01-01 00:02 UTC\n" +
+		">\n" +
+		"> second\n\n" +
+		"> **cheshire137** in `#ops` at 1970-01-01 00:02 UTC\n" +
+		">\n" +
+		"> third"
+	if expected != strings.TrimSpace(actual) {
+		t.Fatal("expected:\n\n", expected, "\n\ngot:\n\n", actual)
+	}
+}
@@ -2,9 +2,11 @@ package mocks
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"path"
 
 	"github.com/rneatherway/gh-slack/internal/slackclient"
 )
@@ -27,16 +29,43 @@ func (m *MockClient) MockSuccessfulAuthResponse() {
 	}
 }
 
+// MockSuccessfulUsersResponse responds to users.list with all of the given
+// users, and to users.info with the requested one.
 func (m *MockClient) MockSuccessfulUsersResponse(fakeUsers []slackclient.User) {
-	json := `{"Ok":true,"Members":[`
-	for i, user := range fakeUsers {
-		json += fmt.Sprintf(`{"ID":"%s","Name":"%s"}`, user.ID, user.Name)
-		if i < len(fakeUsers)-1 {
-			json += ","
+	list, _ := json.Marshal(slackclient.UsersResponse{Ok: true, Members: fakeUsers})
+	m.Next = func(req *http.Request) (*http.Response, error) {
+		body := list
+		if path.Base(req.URL.Path) == "users.info" {
+			body = []byte(`{"Ok":false,"Error":"user_not_found"}`)
+			for _, user := range fakeUsers {
+				if user.ID == req.URL.Query().Get("user") {
+					body, _ = json.Marshal(slackclient.UsersInfoResponse{Ok: true, User: user})
+				}
+			}
 		}
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(bytes.NewReader(body))}, nil
 	}
-	json += `]}`
-	m.Next = func(*http.Request) (*http.Response, error) {
-		return &http.Response{StatusCode: 200, Body: io.NopCloser(bytes.NewReader([]byte(json)))}, nil
+}
+
+// MockResponses responds to each Slack API method with the given body, and
+// fails requests for any other method. A response keyed by the method and
+// query (e.g. "users.info?user=U1") takes precedence over one keyed by the
+// method alone. Calls records the methods requested.
+func (m *MockClient) MockResponses(responses map[string]string, calls *[]string) {
+	m.Next = func(req *http.Request) (*http.Response, error) {
+		method := path.Base(req.URL.Path)
+		if calls != nil {
+			*calls = append(*calls, method)
+		}
+
+		body, ok := responses[method+"?"+req.URL.RawQuery]
+		if !ok {
+			body, ok = responses[method]
+		}
+		if !ok {
+			return nil, fmt.Errorf("unexpected request for %s", req.URL)
+		}
+
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(bytes.NewReader([]byte(body)))}, nil
 	}
 }
@@ -0,0 +1,354 @@
+package slackclient
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// messageArchive stores the messages fetched by History, so that they only
+// need to be fetched once. Each channel's history and each thread is kept in
+// its own file under the team directory:
+//
+//	archive/<channel>/history.json
+//	archive/<channel>/threads/<thread ts>.json
+type messageArchive struct {
+	dir string
+}
+
+// tsSpan is an inclusive range of message timestamps.
+type tsSpan struct {
+	Oldest string `json:"oldest"`
+	Latest string `json:"latest"`
+}
+
+// archivedConversation holds the archived messages from a channel or thread,
+// sorted by timestamp. Complete lists the spans for which every message was
+// fetched, so that we know when the archive can be used without asking Slack.
+type archivedConversation struct {
+	Messages []Message `json:"messages"`
+	Complete []tsSpan  `json:"complete"`
+}
+
+// compareTimestamps orders Slack timestamps, which are seconds and
+// microseconds since the epoch separated by ".".
+func compareTimestamps(a, b string) int {
+	aSeconds, aMicros, _ := strings.Cut(a, ".")
+	bSeconds, bMicros, _ := strings.Cut(b, ".")
+	return cmp.Or(
+		cmp.Compare(len(aSeconds), len(bSeconds)),
+		strings.Compare(aSeconds, bSeconds),
+		strings.Compare(aMicros, bMicros))
+}
+
+// ValidTimestamp reports whether ts is a message timestamp, seconds and
+// microseconds since the epoch, e.g. 1648028606.962719.
+func ValidTimestamp(ts string) bool {
+	seconds, micros, ok := strings.Cut(ts, ".")
+	return ok && isDigits(seconds) && isDigits(micros) && len(micros) == 6
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+var conversationIDRE = regexp.MustCompile("^[CDG][A-Z0-9]+$")
+
+// ValidConversationID reports whether id is the ID of a channel, private
+// channel or direct message.
+func ValidConversationID(id string) bool {
+	return conversationIDRE.MatchString(id)
+}
+
+func timestampAt(t time.Time) string {
+	return fmt.Sprintf("%d.%06d", t.Unix(), t.Nanosecond()/1000)
+}
+
+// UseArchive stores messages fetched by History in the team's message
+// archive, and uses archived messages in preference to fetching them again.
+// For an offline client, History only uses the archive.
+func (c *SlackClient) UseArchive() {
+	c.archive = &messageArchive{dir: path.Join(c.teamDir, "archive")}
+}
+
+// path returns the archive file of a channel's history or of a thread. The IDs
+// may come from an imported export, so must not lead outside the archive.
+func (a *messageArchive) path(channelID, thread string) (string, error) {
+	for _, component := range []string{channelID, thread} {
+		if strings.ContainsAny(component, `/\`) || strings.Contains(component, "..") {
+			return "", fmt.Errorf("invalid conversation or thread %q in the message archive", component)
+		}
+	}
+
+	if thread == "" {
+		return path.Join(a.dir, channelID, "history.json"), nil
+	}
+	return path.Join(a.dir, channelID, "threads", thread+".json"), nil
+}
+
+func (a *messageArchive) load(channelID, thread string) (*archivedConversation, error) {
+	filePath, err := a.path(channelID, thread)
+	if err != nil {
+		return nil, err
+	}
+
+	conversation := &archivedConversation{}
+	content, err := os.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return conversation, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return conversation, json.Unmarshal(content, conversation)
+}
+
+// add merges newly fetched messages, complete for the given span, into the
+// archive file. Messages that are already archived are replaced by the newer
+// copy.
+func (a *messageArchive) add(channelID, thread string, messages []Message, complete tsSpan) error {
+	filePath, err := a.path(channelID, thread)
+	if err != nil {
+		return err
+	}
+
+	return updateFile(filePath, func(content []byte) ([]byte, error) {
+		conversation := &archivedConversation{}
+		if content != nil {
+			err := json.Unmarshal(content, conversation)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		conversation.merge(messages, complete)
+		return json.Marshal(conversation)
+	})
+}
+
+func (conversation *archivedConversation) merge(messages []Message, complete tsSpan) {
+	byTS := make(map[string]Message, len(conversation.Messages)+len(messages))
+	for _, message := range conversation.Messages {
+		byTS[message.Ts] = message
+	}
+	for _, message := range messages {
+		byTS[message.Ts] = message
+	}
+
+	conversation.Messages = conversation.Messages[:0]
+	for _, message := range byTS {
+		conversation.Messages = append(conversation.Messages, message)
+	}
+	slices.SortFunc(conversation.Messages, func(a, b Message) int {
+		return compareTimestamps(a.Ts, b.Ts)
+	})
+
+	// Spans that overlap are joined, but adjacent spans are not, as there may
+	// be messages between them that we haven't seen.
+	spans := append(conversation.Complete, complete)
+	slices.SortFunc(spans, func(a, b tsSpan) int {
+		return compareTimestamps(a.Oldest, b.Oldest)
+	})
+
+	conversation.Complete = spans[:1]
+	for _, span := range spans[1:] {
+		last := &conversation.Complete[len(conversation.Complete)-1]
+		if compareTimestamps(span.Oldest, last.Latest) > 0 {
+			conversation.Complete = append(conversation.Complete, span)
+		} else if compareTimestamps(span.Latest, last.Latest) > 0 {
+			last.Latest = span.Latest
+		}
+	}
+}
+
+// from returns the archived messages from oldest (inclusive) for which the
+// archive is complete.
+func (conversation *archivedConversation) from(oldest string) []Message {
+	for _, span := range conversation.Complete {
+		if compareTimestamps(span.Oldest, oldest) > 0 || compareTimestamps(oldest, span.Latest) > 0 {
+			continue
+		}
+
+		var messages []Message
+		for _, message := range conversation.Messages {
+			if compareTimestamps(message.Ts, oldest) >= 0 && compareTimestamps(message.Ts, span.Latest) <= 0 {
+				messages = append(messages, message)
+			}
+		}
+		return messages
+	}
+
+	return nil
+}
+
+// message returns the archived message with the given timestamp.
+func (conversation *archivedConversation) message(ts string) (Message, bool) {
+	i, found := slices.BinarySearchFunc(conversation.Messages, ts, func(m Message, ts string) int {
+		return compareTimestamps(m.Ts, ts)
+	})
+	if !found {
+		return Message{}, false
+	}
+	return conversation.Messages[i], true
+}
+
+// archivedHistory is History using the archive. Only messages newer than
+// those already archived are fetched.
+func (c *SlackClient) archivedHistory(channelID, startTimestamp, thread string, limit int) (*HistoryResponse, error) {
+	if thread == "" {
+		var err error
+		thread, err = c.threadStartingAt(channelID, startTimestamp, limit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var fetch func(oldest string, limit int) ([]Message, tsSpan, error)
+	if thread != "" {
+		fetch = func(oldest string, limit int) ([]Message, tsSpan, error) {
+			fetchedAt := timestampAt(time.Now())
+			response, err := c.replies(channelID, thread, oldest, limit)
+			if err != nil {
+				return nil, tsSpan{}, err
+			}
+
+			// Replies are returned oldest first, after the root message.
+			complete := tsSpan{Oldest: oldest, Latest: fetchedAt}
+			if response.HasMore {
+				complete.Latest = response.Messages[len(response.Messages)-1].Ts
+			}
+			return response.Messages, complete, nil
+		}
+	} else {
+		fetch = func(oldest string, limit int) ([]Message, tsSpan, error) {
+			fetchedAt := timestampAt(time.Now())
+			response, err := c.channelHistory(channelID, oldest, limit)
+			if err != nil {
+				return nil, tsSpan{}, err
+			}
+
+			complete := tsSpan{Oldest: oldest, Latest: fetchedAt}
+			if response.HasMore && len(response.Messages) > 0 {
+				// The page may come from either end, but has no gaps.
+				complete.Oldest, complete.Latest = response.Messages[0].Ts, response.Messages[0].Ts
+				for _, message := range response.Messages {
+					if compareTimestamps(message.Ts, complete.Oldest) < 0 {
+						complete.Oldest = message.Ts
+					}
+					if compareTimestamps(message.Ts, complete.Latest) > 0 {
+						complete.Latest = message.Ts
+					}
+				}
+			}
+			return response.Messages, complete, nil
+		}
+	}
+
+	conversation, err := c.archive.load(channelID, thread)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := conversation.from(startTimestamp)
+	if c.offline {
+		if len(messages) == 0 {
+			return nil, fmt.Errorf("message %s in %s is not in the local archive: %w", startTimestamp, channelID, ErrOffline)
+		}
+	} else if len(messages) < limit {
+		// Fetch from the newest archived message, so that the new messages
+		// join up with those we already have.
+		oldest := startTimestamp
+		if len(messages) > 0 {
+			oldest = messages[len(messages)-1].Ts
+		}
+
+		fetched, complete, err := fetch(oldest, limit-len(messages)+1)
+		if err != nil {
+			return nil, err
+		}
+
+		err = c.archive.add(channelID, thread, fetched, complete)
+		if err != nil {
+			return nil, err
+		}
+
+		conversation.merge(fetched, complete)
+		messages = conversation.from(startTimestamp)
+
+		// If the fetched page didn't join up with the archived messages, there
+		// is a gap before it, but it should still be shown.
+		seen := make(map[string]bool, len(messages))
+		for _, message := range messages {
+			seen[message.Ts] = true
+		}
+		for _, message := range fetched {
+			if !seen[message.Ts] && compareTimestamps(message.Ts, startTimestamp) >= 0 {
+				messages = append(messages, message)
+			}
+		}
+		slices.SortFunc(messages, func(a, b Message) int {
+			return compareTimestamps(a.Ts, b.Ts)
+		})
+	}
+
+	if len(messages) > limit {
+		messages = messages[:limit]
+	}
+
+	return &HistoryResponse{Ok: true, Messages: messages}, nil
+}
+
+// threadStartingAt returns the thread rooted at the given message, or "" if
+// the message has no replies and so the channel history should be read.
+func (c *SlackClient) threadStartingAt(channelID, ts string, limit int) (string, error) {
+	thread, err := c.archive.load(channelID, ts)
+	if err != nil {
+		return "", err
+	}
+	if len(thread.Messages) > 0 {
+		return ts, nil
+	}
+
+	history, err := c.archive.load(channelID, "")
+	if err != nil {
+		return "", err
+	}
+	if message, ok := history.message(ts); ok {
+		if message.ReplyCount != 0 {
+			return ts, nil
+		}
+		return "", nil
+	}
+
+	if c.offline {
+		return "", fmt.Errorf("message %s in %s is not in the local archive: %w", ts, channelID, ErrOffline)
+	}
+
+	response, err := c.replies(channelID, ts, "", limit)
+	if err != nil {
+		return "", err
+	}
+
+	if len(response.Messages) == 0 || response.Messages[0].ReplyCount == 0 {
+		return "", nil
+	}
+
+	complete := tsSpan{Oldest: ts, Latest: timestampAt(time.Now())}
+	if response.HasMore {
+		complete.Latest = response.Messages[len(response.Messages)-1].Ts
+	}
+
+	return ts, c.archive.add(channelID, ts, response.Messages, complete)
+}
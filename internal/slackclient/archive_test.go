@@ -0,0 +1,178 @@
+package slackclient
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func messageTimestamps(messages []Message) string {
+	ts := make([]string, 0, len(messages))
+	for _, message := range messages {
+		ts = append(ts, message.Ts)
+	}
+	return fmt.Sprint(ts)
+}
+
+func TestArchivedConversationFrom(t *testing.T) {
+	conversation := &archivedConversation{}
+	conversation.merge([]Message{{Ts: "100.000001"}, {Ts: "200.000000"}}, tsSpan{"100.000000", "250.000000"})
+	conversation.merge([]Message{{Ts: "300.000000"}}, tsSpan{"260.000000", "300.000000"})
+	conversation.merge([]Message{{Ts: "250.000000"}, {Ts: "280.000000"}}, tsSpan{"250.000000", "280.000000"})
+	conversation.merge([]Message{{Ts: "1000.000000"}}, tsSpan{"1000.000000", "1000.000000"})
+
+	tests := []struct {
+		oldest   string
+		expected string
+	}{
+		{"100.000000", "[100.000001 200.000000 250.000000 280.000000 300.000000]"},
+		{"200.000000", "[200.000000 250.000000 280.000000 300.000000]"},
+		{"99.000000", "[]"},
+		{"500.000000", "[]"},
+		{"1000.000000", "[1000.000000]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.oldest, func(t *testing.T) {
+			actual := messageTimestamps(conversation.from(tt.oldest))
+			if actual != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestArchivedHistoryOnlyFetchesNewMessages(t *testing.T) {
+	var calls []string
+	responses := map[string]string{
+		"conversations.replies": `{"ok":true,"messages":[{"ts":"100.000000"}]}`,
+		"conversations.history": `{"ok":true,"messages":[{"ts":"100.000000"},{"ts":"200.000000"}]}`,
+	}
+	rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		response, err := respond(responses, &calls).RoundTrip(req)
+		calls[len(calls)-1] += "?oldest=" + req.URL.Query().Get("oldest")
+		return response, err
+	})
+
+	client := newNull(t.TempDir(), "test", rt)
+	client.UseArchive()
+
+	history, err := client.History("C1", "100.000000", "", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := messageTimestamps(history.Messages); actual != "[100.000000 200.000000]" {
+		t.Errorf("unexpected messages %s", actual)
+	}
+	expectCalls(t, calls, "conversations.replies?oldest=", "conversations.history?oldest=100.000000")
+
+	calls = nil
+	responses["conversations.history"] = `{"ok":true,"messages":[{"ts":"300.000000"},{"ts":"200.000000"}]}`
+	history, err = client.History("C1", "100.000000", "", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := messageTimestamps(history.Messages); actual != "[100.000000 200.000000 300.000000]" {
+		t.Errorf("unexpected messages %s", actual)
+	}
+	expectCalls(t, calls, "conversations.history?oldest=200.000000")
+
+	calls = nil
+	client.offline = true
+	history, err = client.History("C1", "200.000000", "", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := messageTimestamps(history.Messages); actual != "[200.000000 300.000000]" {
+		t.Errorf("unexpected messages %s", actual)
+	}
+	expectCalls(t, calls)
+}
+
+func TestArchivedHistoryWithGapKeepsOldestMessages(t *testing.T) {
+	var calls []string
+	responses := map[string]string{
+		"conversations.replies": `{"ok":true,"messages":[{"ts":"100.000000"}]}`,
+		"conversations.history": `{"ok":true,"has_more":true,"messages":[{"ts":"100.000000"}]}`,
+	}
+	client := newNull(t.TempDir(), "test", respond(responses, &calls))
+	client.UseArchive()
+
+	_, err := client.History("C1", "100.000000", "", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A page of newer messages, newest first, that doesn't reach back to the
+	// archived ones.
+	responses["conversations.history"] = `{"ok":true,"has_more":true,"messages":[
+		{"ts":"500.000000"},{"ts":"400.000000"},{"ts":"300.000000"}]}`
+	history, err := client.History("C1", "100.000000", "", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := messageTimestamps(history.Messages); actual != "[100.000000 300.000000 400.000000]" {
+		t.Errorf("unexpected messages %s", actual)
+	}
+}
+
+func TestArchivedThreadOffline(t *testing.T) {
+	var calls []string
+	client := newNull(t.TempDir(), "test", respond(map[string]string{
+		"conversations.replies": `{"ok":true,"messages":[
+			{"ts":"100.000000","reply_count":2},
+			{"ts":"110.000000"},
+			{"ts":"120.000000"}]}`,
+	}, &calls))
+	client.UseArchive()
+
+	history, err := client.History("C1", "100.000000", "", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := messageTimestamps(history.Messages); actual != "[100.000000 110.000000 120.000000]" {
+		t.Errorf("unexpected messages %s", actual)
+	}
+	expectCalls(t, calls, "conversations.replies")
+
+	calls = nil
+	client.offline = true
+	for _, tt := range []struct{ start, thread, expected string }{
+		{"100.000000", "", "[100.000000 110.000000 120.000000]"},
+		{"110.000000", "100.000000", "[110.000000 120.000000]"},
+	} {
+		history, err = client.History("C1", tt.start, tt.thread, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := messageTimestamps(history.Messages); actual != tt.expected {
+			t.Errorf("expected %s from %s, got %s", tt.expected, tt.start, actual)
+		}
+	}
+	expectCalls(t, calls)
+
+	_, err = client.History("C1", "500.000000", "", 3)
+	if err == nil {
+		t.Error("expected an error for a message that is not archived")
+	}
+}
+
+func TestArchivePathStaysInArchive(t *testing.T) {
+	archive := &messageArchive{dir: t.TempDir()}
+	for _, tt := range []struct{ channelID, thread string }{
+		{"../C1", ""},
+		{"C1", "../../100.000000"},
+		{`C1\..`, ""},
+		{"C1", "100.000000/x"},
+	} {
+		_, err := archive.path(tt.channelID, tt.thread)
+		if err == nil {
+			t.Errorf("expected %q and %q to be rejected", tt.channelID, tt.thread)
+		}
+	}
+
+	_, err := archive.path("C1", "100.000000")
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,250 @@
+package slackclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/rneatherway/slack"
+)
+
+// EnvSlackTokenFile names the environment variable holding the path of a file
+// containing a Slack token, as an alternative to setting SLACK_TOKEN.
+const EnvSlackTokenFile = "SLACK_TOKEN_FILE"
+
+// AuthSource selects where the credentials for the Slack API come from.
+type AuthSource string
+
+const (
+	// AuthSourceAuto tries each of the other sources in turn, in the order
+	// they are listed here.
+	AuthSourceAuto AuthSource = "auto"
+	// AuthSourceEnv uses SLACK_TOKEN, with SLACK_COOKIES for a session token.
+	AuthSourceEnv AuthSource = "env"
+	// AuthSourceFile reads a token from the file named by SLACK_TOKEN_FILE or
+	// AuthOptions.TokenFile.
+	AuthSourceFile AuthSource = "file"
+	// AuthSourceKeyring uses the credentials stored by "gh-slack auth login".
+	AuthSourceKeyring AuthSource = "keyring"
+	// AuthSourceDesktop extracts a session token and cookie from the Slack
+	// desktop app.
+	AuthSourceDesktop AuthSource = "desktop"
+)
+
+func ParseAuthSource(s string) (AuthSource, error) {
+	switch source := AuthSource(s); source {
+	case AuthSourceAuto, AuthSourceEnv, AuthSourceFile, AuthSourceKeyring, AuthSourceDesktop:
+		return source, nil
+	}
+
+	return "", fmt.Errorf("unknown auth source %q, expected one of %q, %q, %q, %q or %q",
+		s, AuthSourceAuto, AuthSourceEnv, AuthSourceFile, AuthSourceKeyring, AuthSourceDesktop)
+}
+
+// AuthOptions configures how New finds credentials. The zero value tries
+// every source.
+type AuthOptions struct {
+	Source AuthSource
+	// TokenFile is used if SLACK_TOKEN_FILE is not set.
+	TokenFile string
+}
+
+// Credentials authenticate requests to the Slack API. Session tokens
+// (xoxc-...) also need the cookies of the session, but bot (xoxb-...) and
+// user (xoxp-...) tokens do not.
+type Credentials struct {
+	Token   string            `json:"token"`
+	Cookies map[string]string `json:"cookies,omitempty"`
+	// Source describes where the credentials came from.
+	Source string `json:"-"`
+}
+
+// errNoCredentials is returned by a source that is not configured, so that the
+// next source is tried.
+var errNoCredentials = errors.New("not configured")
+
+// desktopAuth is replaced in tests, which must not read the real desktop app.
+var desktopAuth = slack.GetCookieAuth
+
+type authSource struct {
+	source      AuthSource
+	description string
+	credentials func(team string, opts AuthOptions) (*Credentials, error)
+}
+
+var authSources = []authSource{
+	{AuthSourceEnv, "the " + slack.EnvSlackToken + " environment variable", envCredentials},
+	{AuthSourceFile, "a token file", fileCredentials},
+	{AuthSourceKeyring, "the stored credentials", keyringCredentials},
+	{AuthSourceDesktop, "the Slack desktop app", desktopCredentials},
+}
+
+// ResolveCredentials returns the credentials for the team from the first
+// source that is configured. A source that is configured but unusable, such
+// as a session token without cookies, is an error rather than skipped.
+func ResolveCredentials(team string, opts AuthOptions) (*Credentials, error) {
+	var tried []string
+	for _, source := range authSources {
+		if opts.Source != "" && opts.Source != AuthSourceAuto && opts.Source != source.source {
+			continue
+		}
+
+		credentials, err := source.credentials(team, opts)
+		if err == nil {
+			if credentials.Source == "" {
+				credentials.Source = source.description
+			}
+			return credentials, nil
+		}
+
+		// The desktop app is the last resort, so any failure is reported
+		// alongside the other sources.
+		if !errors.Is(err, errNoCredentials) && source.source != AuthSourceDesktop {
+			return nil, fmt.Errorf("failed to use credentials from %s: %w", source.description, err)
+		}
+
+		tried = append(tried, fmt.Sprintf("  %s: %v", source.description, err))
+	}
+
+	return nil, fmt.Errorf("no Slack credentials found for team %q, tried:\n%s", team, strings.Join(tried, "\n"))
+}
+
+func envCredentials(string, AuthOptions) (*Credentials, error) {
+	token := os.Getenv(slack.EnvSlackToken)
+	if token == "" {
+		return nil, fmt.Errorf("%w, %s is not set", errNoCredentials, slack.EnvSlackToken)
+	}
+
+	return parseCredentials(token, os.Getenv(slack.EnvSlackCookies))
+}
+
+// fileCredentials reads credentials from a file, in the form accepted by
+// ParseCredentials.
+func fileCredentials(_ string, opts AuthOptions) (*Credentials, error) {
+	file := os.Getenv(EnvSlackTokenFile)
+	if file == "" {
+		file = opts.TokenFile
+	}
+	if file == "" {
+		return nil, fmt.Errorf("%w, set %s or the token_file key", errNoCredentials, EnvSlackTokenFile)
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseCredentials(string(content))
+}
+
+// ParseCredentials parses a token, optionally followed on the next line by
+// cookies in the same form as SLACK_COOKIES.
+func ParseCredentials(s string) (*Credentials, error) {
+	token, cookies, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return parseCredentials(strings.TrimSpace(token), strings.TrimSpace(cookies))
+}
+
+func desktopCredentials(team string, _ AuthOptions) (*Credentials, error) {
+	auth, err := desktopAuth(team)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Credentials{Token: auth.Token, Cookies: auth.Cookies}, nil
+}
+
+func parseCredentials(token, cookies string) (*Credentials, error) {
+	credentials := &Credentials{Token: token}
+	if cookies != "" {
+		values, err := url.ParseQuery(cookies)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cookies: %w", err)
+		}
+
+		credentials.Cookies = make(map[string]string, len(values))
+		for key, value := range values {
+			if len(value) != 1 {
+				return nil, fmt.Errorf("cookie %q has %d values", key, len(value))
+			}
+			credentials.Cookies[key] = value[0]
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(token, "xoxc-"):
+		if credentials.Cookies["d"] == "" {
+			return nil, fmt.Errorf("session tokens (xoxc-...) also need the \"d\" cookie, e.g. in %s", slack.EnvSlackCookies)
+		}
+	case strings.HasPrefix(token, "xapp-"):
+		return nil, fmt.Errorf("app-level tokens (xapp-...) can only be used for Socket Mode, in %s", EnvSlackAppToken)
+	}
+
+	return credentials, nil
+}
+
+// cookieTransport adds the session cookies to each request to Slack, which
+// the slack library only does for credentials it found itself. Requests to
+// other hosts, such as redirects, are sent without them.
+type cookieTransport struct {
+	cookies map[string]string
+	next    http.RoundTripper
+}
+
+func (t cookieTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isSlackHost(req.URL.Hostname()) {
+		return t.next.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	for name, value := range t.cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+	return t.next.RoundTrip(req)
+}
+
+// GovSlackSuffix ends the names of GovSlack teams, which are served from
+// slack-gov.com rather than slack.com, e.g. "agency.slack-gov.com".
+const GovSlackSuffix = ".slack-gov.com"
+
+// govSlackTransport sends requests for slack.com to slack-gov.com instead,
+// as the slack library only knows about slack.com. Requests already for
+// slack-gov.com, such as file downloads, are sent as they are.
+type govSlackTransport struct {
+	next http.RoundTripper
+}
+
+func (t govSlackTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if subdomain, ok := strings.CutSuffix(req.URL.Host, ".slack.com"); ok {
+		req = req.Clone(req.Context())
+		req.URL.Host = subdomain + GovSlackSuffix
+		req.Host = ""
+	}
+	return t.next.RoundTrip(req)
+}
+
+// newAuthenticatedClient returns a slack library client using the credentials,
+// and the HTTP client it uses, for requests outside the API such as file
+// downloads.
+func newAuthenticatedClient(team string, credentials *Credentials) (*slack.Client, *http.Client) {
+	team, gov := strings.CutSuffix(team, GovSlackSuffix)
+	client := slack.NewClient(team)
+	client.WithTokenAuth(credentials.Token)
+
+	transport := http.DefaultTransport
+	if gov {
+		transport = govSlackTransport{transport}
+	}
+	if len(credentials.Cookies) > 0 {
+		transport = cookieTransport{credentials.Cookies, transport}
+	}
+
+	httpClient := http.DefaultClient
+	if transport != http.DefaultTransport {
+		httpClient = &http.Client{Transport: transport}
+		client.WithHTTPClient(httpClient)
+	}
+	return client, httpClient
+}
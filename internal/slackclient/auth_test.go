@@ -0,0 +1,301 @@
+package slackclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rneatherway/slack"
+)
+
+// withoutSystemKeyring keeps stored credentials in a temporary directory,
+// using only the encrypted file.
+func withoutSystemKeyring(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+
+	systemKeyring = func() (keyring, error) {
+		return nil, errors.New("no system keyring")
+	}
+	t.Cleanup(func() { systemKeyring = openSystemKeyring })
+}
+
+// withoutAuthEnvironment clears the credentials in the environment and stops
+// the desktop app from being used, returning how often it was asked.
+func withoutAuthEnvironment(t *testing.T) *int {
+	t.Setenv(slack.EnvSlackToken, "")
+	t.Setenv(slack.EnvSlackCookies, "")
+	t.Setenv(EnvSlackTokenFile, "")
+	withoutSystemKeyring(t)
+
+	calls := 0
+	desktopAuth = func(string) (*slack.Auth, error) {
+		calls++
+		return nil, errors.New("no cookie found")
+	}
+	t.Cleanup(func() { desktopAuth = slack.GetCookieAuth })
+	return &calls
+}
+
+func TestResolveCredentialsPrecedence(t *testing.T) {
+	withoutAuthEnvironment(t)
+	file := filepath.Join(t.TempDir(), "token")
+	err := os.WriteFile(file, []byte("xoxb-file\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	credentials, err := ResolveCredentials("test", AuthOptions{TokenFile: file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if credentials.Token != "xoxb-file" || credentials.Source != "a token file" {
+		t.Errorf("expected the token file, got %q from %s", credentials.Token, credentials.Source)
+	}
+
+	t.Setenv(slack.EnvSlackToken, "xoxp-env")
+	credentials, err = ResolveCredentials("test", AuthOptions{TokenFile: file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if credentials.Token != "xoxp-env" {
+		t.Errorf("expected SLACK_TOKEN to take precedence, got %q", credentials.Token)
+	}
+
+	credentials, err = ResolveCredentials("test", AuthOptions{Source: AuthSourceFile, TokenFile: file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if credentials.Token != "xoxb-file" {
+		t.Errorf("expected only the token file to be used, got %q", credentials.Token)
+	}
+}
+
+func TestResolveCredentialsNamesSourcesTried(t *testing.T) {
+	desktopCalls := withoutAuthEnvironment(t)
+
+	_, err := ResolveCredentials("test", AuthOptions{})
+	if err == nil {
+		t.Fatal("expected an error without credentials")
+	}
+	for _, expected := range []string{slack.EnvSlackToken, EnvSlackTokenFile, "gh-slack auth login", "desktop app: no cookie found"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error to mention %q, got %q", expected, err)
+		}
+	}
+	if *desktopCalls != 1 {
+		t.Errorf("expected the desktop app to be tried once, got %d", *desktopCalls)
+	}
+}
+
+func TestResolveCredentialsSessionTokenNeedsCookies(t *testing.T) {
+	desktopCalls := withoutAuthEnvironment(t)
+	t.Setenv(slack.EnvSlackToken, "xoxc-session")
+
+	_, err := ResolveCredentials("test", AuthOptions{})
+	if err == nil || !strings.Contains(err.Error(), slack.EnvSlackCookies) {
+		t.Errorf("expected an error about the missing cookies, got %v", err)
+	}
+	if *desktopCalls != 0 {
+		t.Error("expected a misconfigured source not to fall through to the desktop app")
+	}
+
+	t.Setenv(slack.EnvSlackCookies, "d=secret%2Bcookie")
+	credentials, err := ResolveCredentials("test", AuthOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if credentials.Cookies["d"] != "secret+cookie" {
+		t.Errorf("unexpected cookies %v", credentials.Cookies)
+	}
+}
+
+func TestCookieTransportAddsCookies(t *testing.T) {
+	var cookie string
+	transport := cookieTransport{map[string]string{"d": "secret"}, roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		c, err := req.Cookie("d")
+		if err != nil {
+			return nil, err
+		}
+		cookie = c.Value
+		return &http.Response{StatusCode: 200, Body: http.NoBody}, nil
+	})}
+
+	req, err := http.NewRequest("GET", "https://test.slack.com/api/auth.test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = transport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cookie != "secret" {
+		t.Errorf("expected the d cookie to be sent, got %q", cookie)
+	}
+	if len(req.Cookies()) != 0 {
+		t.Error("expected the original request not to be modified")
+	}
+}
+
+func TestCookieTransportOnlyAddsCookiesForSlack(t *testing.T) {
+	cookies := map[string]string{}
+	var hosts []string
+	transport := cookieTransport{map[string]string{"d": "secret"}, roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		hosts = append(hosts, req.URL.Host)
+		if c, err := req.Cookie("d"); err == nil {
+			cookies[req.URL.Host] = c.Value
+		}
+		if req.URL.Host == "test.slack.com" {
+			return &http.Response{StatusCode: 302, Header: http.Header{"Location": {"https://example.com/files/log.txt"}}, Body: http.NoBody}, nil
+		}
+		return &http.Response{StatusCode: 200, Body: http.NoBody}, nil
+	})}
+
+	client := &http.Client{Transport: transport}
+	resp, err := client.Get("https://test.slack.com/files-pri/T1-F1/log.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if len(hosts) != 2 || hosts[1] != "example.com" {
+		t.Fatalf("expected the request to be redirected, got requests to %v", hosts)
+	}
+	if cookies["test.slack.com"] != "secret" {
+		t.Errorf("expected the d cookie to be sent to Slack, got %v", cookies)
+	}
+	if _, ok := cookies["example.com"]; ok {
+		t.Error("expected the d cookie not to be sent to another host")
+	}
+}
+
+func TestStoredCredentialsArePreferredToTheDesktopApp(t *testing.T) {
+	desktopCalls := withoutAuthEnvironment(t)
+
+	where, err := StoreCredentials("test", &Credentials{Token: "xoxc-stored", Cookies: map[string]string{"d": "secret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(where, "encrypted file") {
+		t.Errorf("expected the credentials to be stored in the encrypted file, got %s", where)
+	}
+
+	credentials, err := ResolveCredentials("test", AuthOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if credentials.Token != "xoxc-stored" || credentials.Cookies["d"] != "secret" || credentials.Source != where {
+		t.Errorf("unexpected credentials %+v", credentials)
+	}
+	if *desktopCalls != 0 {
+		t.Error("expected the desktop app not to be used")
+	}
+
+	_, err = StoredCredentials("other")
+	if !errors.Is(err, ErrNotLoggedIn) {
+		t.Errorf("expected no credentials for another team, got %v", err)
+	}
+
+	err = DeleteStoredCredentials("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = DeleteStoredCredentials("test")
+	if !errors.Is(err, ErrNotLoggedIn) {
+		t.Errorf("expected to be logged out, got %v", err)
+	}
+}
+
+func TestFileKeyringIsEncrypted(t *testing.T) {
+	dir := t.TempDir()
+	k := &fileKeyring{path: filepath.Join(dir, "credentials.enc"), keyPath: filepath.Join(dir, "key", "credentials.key")}
+
+	err := k.set("test", &Credentials{Token: "xoxp-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(k.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "xoxp-secret") {
+		t.Error("expected the token to be encrypted")
+	}
+
+	err = os.WriteFile(k.keyPath, make([]byte, 32), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = k.get("test")
+	if err == nil {
+		t.Error("expected an error decrypting with a different key")
+	}
+}
+
+func TestGovSlackTeamsUseSlackGov(t *testing.T) {
+	var host string
+	client, _ := newAuthenticatedClient("agency"+GovSlackSuffix, &Credentials{Token: "xoxp-token"})
+	client.WithHTTPClient(&http.Client{Transport: govSlackTransport{roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		host = req.URL.Host
+		return &http.Response{StatusCode: 200, Body: http.NoBody}, nil
+	})}})
+
+	_, err := client.API(context.Background(), "GET", "auth.test", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host != "agency.slack-gov.com" {
+		t.Errorf("expected the request to go to GovSlack, got %s", host)
+	}
+}
+
+func TestGovSlackTeamsUseSlackGovForSocketMode(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv(EnvSlackAppToken, "xapp-token")
+
+	var host string
+	defaultTransport := http.DefaultTransport
+	http.DefaultTransport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		host = req.URL.Host
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`{"ok":false,"error":"invalid_auth"}`))}, nil
+	})
+	t.Cleanup(func() { http.DefaultTransport = defaultTransport })
+
+	client, err := NewWithCredentials("agency"+GovSlackSuffix, &Credentials{Token: "xoxp-token"}, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	_, err = client.ConnectToSocketMode()
+	if err == nil {
+		t.Fatal("expected the connection to fail")
+	}
+	if host != "agency.slack-gov.com" {
+		t.Errorf("expected Socket Mode to connect through GovSlack, got %s", host)
+	}
+}
+
+func TestOtherAppTokensOnlyFailSocketMode(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv(EnvSlackAppToken, "set-for-something-else")
+
+	client, err := NewWithCredentials("test", &Credentials{Token: "xoxp-token"}, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	_, err = client.ConnectToSocketMode()
+	if err == nil || !strings.Contains(err.Error(), "xapp-") {
+		t.Errorf("expected an error about the app-level token, got %v", err)
+	}
+}
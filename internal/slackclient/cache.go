@@ -0,0 +1,517 @@
+package slackclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// DefaultCacheTTL is how long cached users and channels are used before they
+// are refreshed.
+const DefaultCacheTTL = 7 * 24 * time.Hour
+
+// fullUserRefreshThreshold is the number of unknown users above which it is
+// quicker to download the whole user list than to look each one up.
+const fullUserRefreshThreshold = 20
+
+type CachedUser struct {
+	User
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+type CachedChannel struct {
+	Channel
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+type CachedBot struct {
+	BotProfile
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// Cache holds the users, channels and bots we have seen in a team, keyed by
+// ID. For direct messages the channel name is that of the other user, prefixed
+// with "@".
+type Cache struct {
+	Version int `json:"version"`
+
+	// TeamID and EnterpriseID record which team the cache was populated from,
+	// as the team name used to locate the cache is not a stable identifier.
+	TeamID       string `json:"team_id,omitempty"`
+	EnterpriseID string `json:"enterprise_id,omitempty"`
+
+	Channels map[string]CachedChannel `json:"channels"`
+	Users    map[string]CachedUser    `json:"users"`
+	Bots     map[string]CachedBot     `json:"bots"`
+}
+
+func newCache() Cache {
+	return Cache{
+		Version:  cacheVersion,
+		Channels: map[string]CachedChannel{},
+		Users:    map[string]CachedUser{},
+		Bots:     map[string]CachedBot{},
+	}
+}
+
+// cacheStore persists the cache for a single team. Lookups return nil if the
+// entry is not present.
+type cacheStore interface {
+	Path() string
+	Team() (teamID, enterpriseID string, err error)
+	SetTeam(teamID, enterpriseID string) error
+	User(id string) (*CachedUser, error)
+	UserByName(name string) (*CachedUser, error)
+	PutUsers(users ...CachedUser) error
+	Channel(id string) (*CachedChannel, error)
+	ChannelByName(name string) (*CachedChannel, error)
+	PutChannels(channels ...CachedChannel) error
+	DeleteChannel(id string) error
+	Bot(id string) (*CachedBot, error)
+	PutBot(bot CachedBot) error
+	Contents() (Cache, error)
+	Clear(users, channels bool) error
+	Close() error
+}
+
+// CacheBackend selects how the cache is stored.
+type CacheBackend string
+
+const (
+	// CacheBackendJSON stores the cache in a single JSON file, which is
+	// rewritten on every change.
+	CacheBackendJSON CacheBackend = "json"
+	// CacheBackendSQLite stores the cache in an SQLite database, with indexed
+	// lookups.
+	CacheBackendSQLite CacheBackend = "sqlite"
+)
+
+func ParseCacheBackend(s string) (CacheBackend, error) {
+	switch b := CacheBackend(s); b {
+	case CacheBackendJSON, CacheBackendSQLite:
+		return b, nil
+	}
+
+	return "", fmt.Errorf("unknown cache backend %q, expected %q or %q", s, CacheBackendJSON, CacheBackendSQLite)
+}
+
+// teamDir returns the directory holding the data for a team.
+func teamDir(dataDir, team string) string {
+	return path.Join(dataDir, "teams", url.PathEscape(team))
+}
+
+// migrateLegacyCacheFile moves the single cache file used by earlier versions,
+// which was shared by all teams, to make way for the data directory. It can't
+// tell which team the cache was for, so it is given to the first team used.
+// The migrated entries have no fetch time (see jsonStore.load), so isFresh
+// treats them as expired whatever the TTL, and any that belong to another team
+// are replaced when they are next used.
+func migrateLegacyCacheFile(dataDir, team string) error {
+	info, err := os.Stat(dataDir)
+	if errors.Is(err, os.ErrNotExist) || err == nil && info.IsDir() {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(dataDir)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(dataDir)
+	if err != nil {
+		return err
+	}
+
+	cachePath := jsonStorePath(teamDir(dataDir, team))
+	err = os.MkdirAll(path.Dir(cachePath), 0755)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(cachePath, content, 0644)
+}
+
+// WithCacheTTL sets how long cached users and channels are used before they
+// are refreshed. A TTL of zero means cached entries never expire.
+func (c *SlackClient) WithCacheTTL(users, channels time.Duration) {
+	c.userTTL = users
+	c.channelTTL = channels
+}
+
+// UseCacheBackend switches the cache to the given backend. The first time the
+// SQLite backend is used for a team, it is populated from the JSON cache.
+func (c *SlackClient) UseCacheBackend(backend CacheBackend) error {
+	var store cacheStore
+	var err error
+	switch backend {
+	case CacheBackendJSON:
+		store, err = openJSONStore(jsonStorePath(c.teamDir), c.log)
+	case CacheBackendSQLite:
+		store, err = openSQLiteStore(sqliteStorePath(c.teamDir), c.store)
+	default:
+		err = fmt.Errorf("unknown cache backend %q", backend)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = c.store.Close()
+	c.store = store
+	return err
+}
+
+// Close releases the cache.
+func (c *SlackClient) Close() error {
+	return c.store.Close()
+}
+
+// isFresh reports whether an entry fetched at the given time can be used
+// without refreshing it. A TTL of 0 means entries never expire, except for
+// those with no fetch time, which were migrated from the legacy cache and may
+// belong to another team.
+func isFresh(fetchedAt time.Time, ttl time.Duration) bool {
+	if fetchedAt.IsZero() {
+		return false
+	}
+	return ttl == 0 || time.Since(fetchedAt) < ttl
+}
+
+// channelForCache names direct messages after the other user, as they have no
+// name of their own. If the user can't be found, for example because they
+// have been deleted or are outside the workspace, the direct message is
+// cached without a name rather than failing for the sake of one conversation.
+func (c *SlackClient) channelForCache(channel Channel, fetchedAt time.Time) CachedChannel {
+	if channel.Is_Im {
+		username, err := c.UsernameForID(channel.User)
+		if err != nil {
+			c.log.Printf("Failed to name direct message %s: %s", channel.ID, err)
+		} else {
+			channel.Name = "@" + username
+		}
+	}
+
+	return CachedChannel{Channel: channel, FetchedAt: fetchedAt}
+}
+
+// checkTeam records the IDs of the team (and enterprise, for Enterprise Grid)
+// that the cache belongs to, discarding the cache if it was populated from a
+// different team of the same name. This is done once, before downloading any
+// full lists.
+func (c *SlackClient) checkTeam() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.teamChecked {
+		return nil
+	}
+
+	auth, err := c.AuthTest()
+	if err != nil {
+		return err
+	}
+
+	teamID, enterpriseID, err := c.store.Team()
+	if err != nil {
+		return err
+	}
+
+	if teamID != "" && (teamID != auth.TeamID || enterpriseID != auth.EnterpriseID) {
+		c.log.Printf("Discarding cache for team %s (enterprise %q), now %s (enterprise %q)",
+			teamID, enterpriseID, auth.TeamID, auth.EnterpriseID)
+		err = c.store.Clear(true, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = c.store.SetTeam(auth.TeamID, auth.EnterpriseID)
+	if err != nil {
+		return err
+	}
+
+	c.teamChecked = true
+	return nil
+}
+
+// RefreshUsers downloads the full user list into the cache.
+func (c *SlackClient) RefreshUsers() error {
+	err := c.checkTeam()
+	if err != nil {
+		return err
+	}
+
+	fetchedAt := time.Now()
+	users, err := c.users()
+	if err != nil {
+		return err
+	}
+
+	cached := make([]CachedUser, 0, len(users))
+	for _, user := range users {
+		cached = append(cached, CachedUser{User: user, FetchedAt: fetchedAt})
+	}
+
+	return c.store.PutUsers(cached...)
+}
+
+// RefreshChannels downloads the full conversation list into the cache.
+func (c *SlackClient) RefreshChannels() error {
+	err := c.checkTeam()
+	if err != nil {
+		return err
+	}
+
+	fetchedAt := time.Now()
+	channels, err := c.conversations()
+	if err != nil {
+		return err
+	}
+
+	// Direct messages are named after the other user, so make sure we know
+	// them without looking each one up individually.
+	unknownUsers := 0
+	for _, ch := range channels {
+		if !ch.Is_Im {
+			continue
+		}
+
+		cached, err := c.store.User(ch.User)
+		if err != nil {
+			return err
+		}
+
+		if cached == nil || !isFresh(cached.FetchedAt, c.userTTL) {
+			unknownUsers++
+		}
+	}
+	if unknownUsers > fullUserRefreshThreshold {
+		err = c.RefreshUsers()
+		if err != nil {
+			return err
+		}
+	}
+
+	cached := make([]CachedChannel, 0, len(channels))
+	for _, ch := range channels {
+		if !ch.Is_Channel && !ch.Is_Im && !ch.Is_Mpim {
+			fmt.Fprintf(os.Stderr, "Skipping non-channel %q\n", ch.Name)
+			continue
+		}
+
+		cached = append(cached, c.channelForCache(ch, fetchedAt))
+	}
+
+	return c.store.PutChannels(cached...)
+}
+
+// UserForID returns the user with the given ID. Users that are not cached, or
+// whose cache entry has expired, are fetched with users.info.
+func (c *SlackClient) UserForID(id string) (*User, error) {
+	cached, err := c.store.User(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached != nil && isFresh(cached.FetchedAt, c.userTTL) {
+		return &cached.User, nil
+	}
+
+	user, err := c.userInfo(id)
+	if err != nil && cached != nil && !errors.Is(err, errNotFound) {
+		c.log.Printf("Failed to refresh user %s, using cached value: %s", id, err)
+		return &cached.User, nil
+	}
+
+	return user, err
+}
+
+func (c *SlackClient) UsernameForID(id string) (string, error) {
+	user, err := c.UserForID(id)
+	if err != nil {
+		return "", err
+	}
+
+	return user.Name, nil
+}
+
+// userInfo fetches a single user with users.info and adds it to the cache.
+func (c *SlackClient) userInfo(id string) (*User, error) {
+	body, err := c.get("users.info", map[string]string{"user": id})
+	if err != nil {
+		return nil, fmt.Errorf("no user with id %q: %w", id, err)
+	}
+
+	user := &UsersInfoResponse{}
+	err = json.Unmarshal(body, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Error == "user_not_found" {
+		return nil, fmt.Errorf("%w: no user with id %q", errNotFound, id)
+	}
+
+	if !user.Ok {
+		return nil, fmt.Errorf("users.info response not OK: %s", body)
+	}
+
+	err = c.store.PutUsers(CachedUser{User: user.User, FetchedAt: time.Now()})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user.User, nil
+}
+
+// UserForEmail returns the user with the given email address, fetched with
+// users.lookupByEmail.
+func (c *SlackClient) UserForEmail(email string) (*User, error) {
+	body, err := c.get("users.lookupByEmail", map[string]string{"email": email})
+	if err != nil {
+		return nil, fmt.Errorf("no user with email %q: %w", email, err)
+	}
+
+	user := &UsersInfoResponse{}
+	err = json.Unmarshal(body, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Error == "users_not_found" {
+		return nil, fmt.Errorf("%w: no user with email %q", errNotFound, email)
+	}
+
+	if !user.Ok {
+		return nil, fmt.Errorf("users.lookupByEmail response not OK: %s", body)
+	}
+
+	err = c.store.PutUsers(CachedUser{User: user.User, FetchedAt: time.Now()})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user.User, nil
+}
+
+// UserIDForName returns the ID of the user with the given username, which may
+// be prefixed with "@". The full user list is only downloaded if the user is
+// not cached, or has been renamed.
+func (c *SlackClient) UserIDForName(name string) (string, error) {
+	name = strings.TrimPrefix(name, "@")
+	cached, err := c.store.UserByName(name)
+	if err != nil {
+		return "", err
+	}
+
+	if cached != nil {
+		if isFresh(cached.FetchedAt, c.userTTL) {
+			return cached.ID, nil
+		}
+
+		user, err := c.userInfo(cached.ID)
+		if err == nil && user.Name == name {
+			return user.ID, nil
+		} else if err != nil {
+			c.log.Printf("Failed to refresh user %s: %s", cached.ID, err)
+		}
+	}
+
+	err = c.RefreshUsers()
+	if err != nil {
+		return "", err
+	}
+
+	cached, err = c.store.UserByName(name)
+	if err != nil {
+		return "", err
+	}
+
+	if cached != nil {
+		return cached.ID, nil
+	}
+
+	return "", fmt.Errorf("could not find any user with name %q", name)
+}
+
+// ChannelIDForName returns the ID of the conversation with the given name. As
+// well as channels, this finds group DMs by their name (mpdm-...) and direct
+// messages by the name of the other user prefixed with "@". The full
+// conversation list is only downloaded if the channel is not cached, or has
+// been renamed.
+func (c *SlackClient) ChannelIDForName(name string) (string, error) {
+	cached, err := c.store.ChannelByName(name)
+	if err != nil {
+		return "", err
+	}
+
+	if cached != nil {
+		if isFresh(cached.FetchedAt, c.channelTTL) {
+			return cached.ID, nil
+		}
+
+		refreshed, err := c.refreshChannel(cached.ID)
+		if err != nil {
+			c.log.Printf("Failed to refresh channel %s, using cached value: %s", cached.ID, err)
+			return cached.ID, nil
+		}
+
+		if refreshed != nil && refreshed.Name == name {
+			return refreshed.ID, nil
+		}
+	}
+
+	err = c.RefreshChannels()
+	if err != nil {
+		return "", err
+	}
+
+	cached, err = c.store.ChannelByName(name)
+	if err != nil {
+		return "", err
+	}
+
+	if cached != nil {
+		return cached.ID, nil
+	}
+
+	return "", fmt.Errorf("could not find any channel with name %q", name)
+}
+
+// refreshChannel updates a single cached channel with conversations.info. If
+// the channel no longer exists, it is removed from the cache and nil is
+// returned. Other errors, such as network errors, are returned with the
+// cached channel kept.
+func (c *SlackClient) refreshChannel(id string) (*CachedChannel, error) {
+	channel, err := c.ChannelInfo(id)
+	if errors.Is(err, errNotFound) {
+		c.log.Printf("Channel %s no longer exists: %s", id, err)
+		return nil, c.store.DeleteChannel(id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	cached := c.channelForCache(*channel, time.Now())
+	return &cached, c.store.PutChannels(cached)
+}
+
+// CachePath returns the location of the cache.
+func (c *SlackClient) CachePath() string {
+	return c.store.Path()
+}
+
+// CacheContents returns the cached users, channels and bots.
+func (c *SlackClient) CacheContents() (Cache, error) {
+	return c.store.Contents()
+}
+
+// ClearCache removes the cached users (and bots), channels, or both.
+func (c *SlackClient) ClearCache(users, channels bool) error {
+	return c.store.Clear(users, channels)
+}
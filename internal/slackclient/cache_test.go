@@ -0,0 +1,529 @@
+package slackclient
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// respond answers each API method with the given body, recording the methods
+// called. The mocks package can't be used here as it imports this one.
+func respond(responses map[string]string, calls *[]string) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		method := path.Base(req.URL.Path)
+		*calls = append(*calls, method)
+
+		body, ok := responses[method]
+		if !ok {
+			return nil, fmt.Errorf("unexpected request for %s", req.URL)
+		}
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(bytes.NewReader([]byte(body)))}, nil
+	})
+}
+
+// forEachBackend runs a test against a client using each cache backend.
+func forEachBackend(t *testing.T, dataDir string, team string, rt http.RoundTripper, test func(*testing.T, *SlackClient)) {
+	for _, backend := range []CacheBackend{CacheBackendJSON, CacheBackendSQLite} {
+		t.Run(string(backend), func(t *testing.T) {
+			client := newNull(path.Join(dataDir, string(backend)), team, rt)
+			err := client.UseCacheBackend(backend)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { client.Close() })
+
+			test(t, client)
+		})
+	}
+}
+
+func putUser(t *testing.T, client *SlackClient, user User, fetchedAt time.Time) {
+	t.Helper()
+	err := client.store.PutUsers(CachedUser{User: user, FetchedAt: fetchedAt})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func putChannel(t *testing.T, client *SlackClient, channel Channel, fetchedAt time.Time) {
+	t.Helper()
+	err := client.store.PutChannels(CachedChannel{Channel: channel, FetchedAt: fetchedAt})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func cachedChannel(t *testing.T, client *SlackClient, id string) *CachedChannel {
+	t.Helper()
+	channel, err := client.store.Channel(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return channel
+}
+
+func expectCalls(t *testing.T, actual []string, expected ...string) {
+	t.Helper()
+	if fmt.Sprint(actual) != fmt.Sprint(expected) {
+		t.Errorf("got API calls %q, want %q", actual, expected)
+	}
+}
+
+func TestMigrateLegacyCache(t *testing.T) {
+	dataDir := path.Join(t.TempDir(), "gh-slack")
+	err := os.WriteFile(dataDir, []byte(`{"Channels":{"ops":"C1"},"Users":{"U1":"alice"}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = migrateLegacyCacheFile(dataDir, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := newNull(dataDir, "test", nil)
+	err = client.UseCacheBackend(CacheBackendJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	channel := cachedChannel(t, client, "C1")
+	if channel == nil || channel.Name != "ops" || !channel.FetchedAt.IsZero() {
+		t.Errorf("unexpected channel entry: %+v", channel)
+	}
+
+	user, err := client.store.User("U1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user == nil || user.Name != "alice" || !user.FetchedAt.IsZero() {
+		t.Errorf("unexpected user entry: %+v", user)
+	}
+}
+
+func TestMigratedEntriesExpireWithoutTTL(t *testing.T) {
+	dataDir := path.Join(t.TempDir(), "gh-slack")
+	err := os.WriteFile(dataDir, []byte(`{"Channels":{"ops":"C1"},"Users":{"U1":"alice"}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = migrateLegacyCacheFile(dataDir, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var calls []string
+	client := newNull(dataDir, "test", respond(map[string]string{
+		"users.info":         `{"ok":true,"user":{"id":"U1","name":"alice2"}}`,
+		"conversations.info": `{"ok":true,"channel":{"id":"C1","name":"ops","is_channel":true}}`,
+	}, &calls))
+	err = client.UseCacheBackend(CacheBackendJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Entries normally never expire, but the migrated ones may belong to
+	// another team.
+	client.WithCacheTTL(0, 0)
+
+	name, err := client.UsernameForID("U1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "alice2" {
+		t.Errorf("expected the migrated user to be refreshed, got %q", name)
+	}
+
+	_, err = client.ChannelIDForName("ops")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, calls, "users.info", "conversations.info")
+}
+
+func TestSQLiteCacheImportsJSONCache(t *testing.T) {
+	dataDir := t.TempDir()
+	fetchedAt := time.Now().Truncate(time.Millisecond)
+
+	client := newNull(dataDir, "test", nil)
+	putChannel(t, client, Channel{ID: "C1", Name: "ops", Is_Channel: true}, fetchedAt)
+	putUser(t, client, User{ID: "U1", Name: "alice", Profile: UserProfile{Email: "alice@example.com"}}, time.Time{})
+	err := client.store.SetTeam("T1", "E1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.UseCacheBackend(CacheBackendSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	teamID, enterpriseID, err := client.store.Team()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if teamID != "T1" || enterpriseID != "E1" {
+		t.Errorf("expected team T1 in enterprise E1, got %q in %q", teamID, enterpriseID)
+	}
+
+	channel, err := client.store.ChannelByName("ops")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if channel == nil || channel.ID != "C1" || !channel.FetchedAt.Equal(fetchedAt) {
+		t.Errorf("unexpected channel entry: %+v", channel)
+	}
+
+	user, err := client.store.UserByName("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user == nil || user.Profile.Email != "alice@example.com" || !user.FetchedAt.IsZero() {
+		t.Errorf("unexpected user entry: %+v", user)
+	}
+
+	var version int
+	err = client.store.(*sqliteStore).db.QueryRow("PRAGMA user_version").Scan(&version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != len(sqliteMigrations) {
+		t.Errorf("expected schema version %d, got %d", len(sqliteMigrations), version)
+	}
+}
+
+func TestCacheIsPerTeam(t *testing.T) {
+	for _, backend := range []CacheBackend{CacheBackendJSON, CacheBackendSQLite} {
+		t.Run(string(backend), func(t *testing.T) {
+			dataDir := t.TempDir()
+			for _, open := range []bool{false, true} {
+				for team, id := range map[string]string{"foo": "C1", "bar": "C2"} {
+					client := newNull(dataDir, team, nil)
+					err := client.UseCacheBackend(backend)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					if !open {
+						putChannel(t, client, Channel{ID: id, Name: "ops", Is_Channel: true}, time.Now())
+						client.Close()
+						continue
+					}
+
+					actual, err := client.ChannelIDForName("ops")
+					client.Close()
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					if actual != id {
+						t.Errorf("expected channel %s for team %s, got %s", id, team, actual)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCacheIsDiscardedForDifferentTeam(t *testing.T) {
+	var calls []string
+	rt := respond(map[string]string{
+		"auth.test":          `{"ok":true,"team_id":"T2"}`,
+		"conversations.list": `{"ok":true,"channels":[{"id":"C2","name":"ops","is_channel":true}]}`,
+	}, &calls)
+
+	forEachBackend(t, t.TempDir(), "foo", rt, func(t *testing.T, client *SlackClient) {
+		err := client.store.SetTeam("T1", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		putChannel(t, client, Channel{ID: "C1", Name: "random", Is_Channel: true}, time.Now())
+
+		id, err := client.ChannelIDForName("ops")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != "C2" {
+			t.Errorf("expected C2, got %q", id)
+		}
+
+		if cachedChannel(t, client, "C1") != nil {
+			t.Error("expected entries from the other team to be discarded")
+		}
+
+		teamID, _, err := client.store.Team()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if teamID != "T2" {
+			t.Errorf("expected cache to be recorded as belonging to T2, got %q", teamID)
+		}
+	})
+}
+
+func TestUsernameForIDRefreshesStaleEntry(t *testing.T) {
+	var calls []string
+	rt := respond(map[string]string{
+		"users.info": `{"ok":true,"user":{"id":"U1","name":"alice2"}}`,
+	}, &calls)
+
+	forEachBackend(t, t.TempDir(), "test", rt, func(t *testing.T, client *SlackClient) {
+		calls = nil
+		putUser(t, client, User{ID: "U1", Name: "alice"}, time.Now().Add(-2*DefaultCacheTTL))
+		name, err := client.UsernameForID("U1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != "alice2" {
+			t.Errorf("expected refreshed name alice2, got %q", name)
+		}
+		expectCalls(t, calls, "users.info")
+
+		calls = nil
+		name, err = client.UsernameForID("U1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != "alice2" {
+			t.Errorf("expected fresh cached name alice2, got %q", name)
+		}
+		expectCalls(t, calls)
+	})
+}
+
+func TestChannelIDForNameRefreshesStaleEntry(t *testing.T) {
+	var calls []string
+	rt := respond(map[string]string{
+		"conversations.info": `{"ok":true,"channel":{"id":"C1","name":"ops","is_channel":true}}`,
+	}, &calls)
+
+	forEachBackend(t, t.TempDir(), "test", rt, func(t *testing.T, client *SlackClient) {
+		calls = nil
+		putChannel(t, client, Channel{ID: "C1", Name: "ops", Is_Channel: true}, time.Now().Add(-2*DefaultCacheTTL))
+		id, err := client.ChannelIDForName("ops")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != "C1" {
+			t.Errorf("expected C1, got %q", id)
+		}
+		expectCalls(t, calls, "conversations.info")
+
+		if !isFresh(cachedChannel(t, client, "C1").FetchedAt, DefaultCacheTTL) {
+			t.Error("expected the channel entry to be refreshed")
+		}
+	})
+}
+
+func TestChannelIDForNameKeepsStaleEntryOnError(t *testing.T) {
+	var calls []string
+	// conversations.info fails as it has no response.
+	rt := respond(map[string]string{}, &calls)
+
+	forEachBackend(t, t.TempDir(), "test", rt, func(t *testing.T, client *SlackClient) {
+		calls = nil
+		putChannel(t, client, Channel{ID: "C1", Name: "ops", Is_Channel: true}, time.Now().Add(-2*DefaultCacheTTL))
+		id, err := client.ChannelIDForName("ops")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != "C1" {
+			t.Errorf("expected the cached C1, got %q", id)
+		}
+		expectCalls(t, calls, "conversations.info")
+
+		if cachedChannel(t, client, "C1") == nil {
+			t.Error("expected the channel entry to be kept")
+		}
+	})
+}
+
+func TestChannelIDForNameDropsDeletedChannel(t *testing.T) {
+	var calls []string
+	rt := respond(map[string]string{
+		"conversations.info": `{"ok":false,"error":"channel_not_found"}`,
+		"auth.test":          `{"ok":true,"team_id":"T1"}`,
+		"conversations.list": `{"ok":true,"channels":[{"id":"C2","name":"ops","is_channel":true}]}`,
+	}, &calls)
+
+	forEachBackend(t, t.TempDir(), "test", rt, func(t *testing.T, client *SlackClient) {
+		calls = nil
+		putChannel(t, client, Channel{ID: "C1", Name: "ops", Is_Channel: true}, time.Now().Add(-2*DefaultCacheTTL))
+		id, err := client.ChannelIDForName("ops")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != "C2" {
+			t.Errorf("expected C2, got %q", id)
+		}
+		expectCalls(t, calls, "conversations.info", "auth.test", "conversations.list")
+
+		if cachedChannel(t, client, "C1") != nil {
+			t.Error("expected the deleted channel to be removed")
+		}
+	})
+}
+
+func TestChannelIDForNameFindsRenamedChannel(t *testing.T) {
+	var calls []string
+	rt := respond(map[string]string{
+		"conversations.info": `{"ok":true,"channel":{"id":"C1","name":"ops-old","is_channel":true}}`,
+		"auth.test":          `{"ok":true,"team_id":"T1"}`,
+		"conversations.list": `{"ok":true,"channels":[
+			{"id":"C1","name":"ops-old","is_channel":true},
+			{"id":"C2","name":"ops","is_channel":true}]}`,
+	}, &calls)
+
+	forEachBackend(t, t.TempDir(), "test", rt, func(t *testing.T, client *SlackClient) {
+		calls = nil
+		putChannel(t, client, Channel{ID: "C1", Name: "ops", Is_Channel: true}, time.Now().Add(-2*DefaultCacheTTL))
+		putChannel(t, client, Channel{ID: "C3", Name: "random", Is_Channel: true}, time.Now())
+		id, err := client.ChannelIDForName("ops")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != "C2" {
+			t.Errorf("expected C2, got %q", id)
+		}
+		expectCalls(t, calls, "conversations.info", "auth.test", "conversations.list")
+
+		if cachedChannel(t, client, "C3") == nil {
+			t.Error("expected entries not in the conversation list to be kept")
+		}
+	})
+}
+
+func TestChannelIDForNameWithUnknownDirectMessageUser(t *testing.T) {
+	var calls []string
+	rt := respond(map[string]string{
+		"auth.test": `{"ok":true,"team_id":"T1"}`,
+		"conversations.list": `{"ok":true,"channels":[
+			{"id":"D1","is_im":true,"user":"U09DELETED"},
+			{"id":"C2","name":"ops","is_channel":true}]}`,
+		"users.info": `{"ok":false,"error":"user_not_found"}`,
+	}, &calls)
+
+	forEachBackend(t, t.TempDir(), "test", rt, func(t *testing.T, client *SlackClient) {
+		id, err := client.ChannelIDForName("ops")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != "C2" {
+			t.Errorf("expected C2, got %q", id)
+		}
+
+		if dm := cachedChannel(t, client, "D1"); dm == nil || dm.Name != "" {
+			t.Errorf("expected the direct message to be cached without a name, got %+v", dm)
+		}
+	})
+}
+
+func TestJSONCacheMergesConcurrentWrites(t *testing.T) {
+	cachePath := jsonStorePath(t.TempDir())
+	fetchedAt := time.Now()
+
+	// Each store stands in for a separate process sharing the cache file.
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := range 10 {
+		store, err := openJSONStore(cachePath, log.New(io.Discard, "", 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id := fmt.Sprintf("U%d", i)
+			errs <- store.PutUsers(CachedUser{User: User{ID: id, Name: id}, FetchedAt: fetchedAt})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store, err := openJSONStore(cachePath, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := store.Contents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cache.Users) != 10 {
+		t.Errorf("expected all 10 users to be cached, got %d", len(cache.Users))
+	}
+
+	err = store.PutUsers(CachedUser{User: User{ID: "U0", Name: "stale"}, FetchedAt: fetchedAt.Add(-time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := store.User("U0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Name != "U0" {
+		t.Errorf("expected the more recent entry to be kept, got %q", user.Name)
+	}
+}
+
+func TestClientIsSafeForConcurrentUse(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	responses := respond(map[string]string{
+		"auth.test":          `{"ok":true,"team_id":"T1"}`,
+		"conversations.list": `{"ok":true,"channels":[{"id":"C1","name":"ops","is_channel":true}]}`,
+		"users.info":         `{"ok":true,"user":{"id":"U1","name":"alice"}}`,
+	}, &calls)
+	rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return responses.RoundTrip(req)
+	})
+
+	forEachBackend(t, t.TempDir(), "test", rt, func(t *testing.T, client *SlackClient) {
+		var wg sync.WaitGroup
+		errs := make(chan error, 20)
+		for range 10 {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				_, err := client.ChannelIDForName("ops")
+				errs <- err
+			}()
+			go func() {
+				defer wg.Done()
+				_, err := client.UsernameForID("U1")
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			if err != nil {
+				t.Error(err)
+			}
+		}
+	})
+}
@@ -5,12 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"path"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/rneatherway/slack"
@@ -63,8 +65,10 @@ type RTMConnectResponse struct {
 }
 
 type BotProfile struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	AppID  string `json:"app_id,omitempty"`
+	UserID string `json:"user_id,omitempty"`
 }
 
 func (r *SendMessageResponse) Output(team, channelID string) string {
@@ -81,26 +85,73 @@ type HistoryResponse struct {
 	Messages []Message
 }
 
+type ChannelText struct {
+	Value string `json:"value"`
+}
+
 type Channel struct {
-	ID         string
-	Name       string
-	Is_Channel bool
+	ID          string
+	Name        string
+	Is_Channel  bool
+	Is_Im       bool
+	Is_Mpim     bool
+	Is_Private  bool
+	Is_Archived bool
+	Is_Member   bool
+	Topic       ChannelText `json:"topic"`
+	Purpose     ChannelText `json:"purpose"`
+	NumMembers  int         `json:"num_members,omitempty"`
+	Created     int64       `json:"created,omitempty"`
+	Creator     string      `json:"creator,omitempty"`
+
+	// User is the other participant of a direct message (Is_Im).
+	User string
 }
 
 type ChannelInfoResponse struct {
 	Ok      bool
+	Error   string
 	Channel Channel
 }
 
+type ConversationMembersResponse struct {
+	CursorResponseMetadata
+	Ok      bool
+	Members []string
+}
+
 type ConversationsResponse struct {
 	CursorResponseMetadata
 	Ok       bool
 	Channels []Channel
 }
 
+type AuthTestResponse struct {
+	Ok           bool
+	URL          string
+	Team         string
+	User         string
+	TeamID       string `json:"team_id"`
+	UserID       string `json:"user_id"`
+	EnterpriseID string `json:"enterprise_id"`
+}
+
+type UserProfile struct {
+	BotID       string `json:"bot_id,omitempty"`
+	RealName    string `json:"real_name,omitempty"`
+	DisplayName string `json:"display_name,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Email       string `json:"email,omitempty"`
+}
+
 type User struct {
-	ID   string
-	Name string
+	ID       string
+	Name     string
+	RealName string `json:"real_name,omitempty"`
+	TZ       string `json:"tz,omitempty"`
+	Deleted  bool   `json:"deleted,omitempty"`
+	IsBot    bool   `json:"is_bot"`
+	Profile  UserProfile
 }
 
 type UsersResponse struct {
@@ -110,74 +161,190 @@ type UsersResponse struct {
 }
 
 type UsersInfoResponse struct {
-	Ok   bool
-	User User
+	Ok    bool
+	Error string
+	User  User
 }
 
-type Cache struct {
-	Channels map[string]string
-	Users    map[string]string
-}
+// EnvSlackAppToken names the environment variable holding an app-level token
+// (xapp-...), which is required to connect using Socket Mode.
+const EnvSlackAppToken = "SLACK_APP_TOKEN"
 
+// SlackClient is safe for concurrent use, once it has been configured with
+// WithCacheTTL and UseCacheBackend.
 type SlackClient struct {
-	cachePath string
-	team      string
-	cache     Cache
-	client    *slack.Client
-	log       *log.Logger
-	tz        *time.Location
-}
-
-func New(team string, log *log.Logger) (*SlackClient, error) {
+	teamDir string
+	team    string
+	store   cacheStore
+
+	// mu guards teamChecked, so that auth.test is only called once.
+	mu          sync.Mutex
+	teamChecked bool
+
+	userTTL    time.Duration
+	channelTTL time.Duration
+	nameStyle  NameStyle
+	archive    *messageArchive
+	offline    bool
+	client     *slack.Client
+	// appToken is the app-level token used for Socket Mode, if any.
+	appToken string
+	// httpClient and token are used for requests outside the API, such as
+	// downloading files.
+	httpClient *http.Client
+	token      string
+	log        *log.Logger
+	tz         *time.Location
+}
+
+// DataHome returns the base directory for user data files, following the XDG
+// Base Directory specification.
+func DataHome() (string, error) {
 	dataHome := os.Getenv("XDG_DATA_HOME")
 	if dataHome == "" {
 		home, err := os.UserHomeDir()
 		if err != nil {
-			return nil, err
+			return "", err
 		}
 		dataHome = path.Join(home, ".local", "share")
 	}
-	cachePath := path.Join(dataHome, "gh-slack")
 
+	return dataHome, nil
+}
+
+// DataDir returns the directory where gh-slack stores its data.
+func DataDir() (string, error) {
+	dataHome, err := DataHome()
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(dataHome, "gh-slack"), nil
+}
+
+// New creates a client for the team, using the first credentials found by
+// ResolveCredentials.
+func New(team string, log *log.Logger) (*SlackClient, error) {
+	return NewWithAuth(team, AuthOptions{}, log)
+}
+
+// NewWithAuth is like New, but chooses where credentials come from.
+func NewWithAuth(team string, opts AuthOptions, log *log.Logger) (*SlackClient, error) {
+	credentials, err := ResolveCredentials(team, opts)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Using Slack credentials from %s", credentials.Source)
+
+	return NewWithCredentials(team, credentials, log)
+}
+
+// NewWithCredentials is like New, but uses the given credentials.
+func NewWithCredentials(team string, credentials *Credentials, log *log.Logger) (*SlackClient, error) {
+	client, httpClient := newAuthenticatedClient(team, credentials)
+	c, err := newWithCache(team, client, log)
+	if err != nil {
+		return nil, err
+	}
+	c.httpClient, c.token = httpClient, credentials.Token
+
+	c.appToken = os.Getenv(EnvSlackAppToken)
+
+	return c, nil
+}
+
+// ErrOffline is returned for Slack API requests made by an offline client.
+var ErrOffline = errors.New("not available offline")
+
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, fmt.Errorf("%s: %w", path.Base(req.URL.Path), ErrOffline)
+}
+
+// NewOffline creates a client that only uses data stored locally, such as the
+// cache, so it does not need credentials. Any Slack API request fails with
+// ErrOffline.
+func NewOffline(team string, log *log.Logger) (*SlackClient, error) {
+	httpClient := &http.Client{Transport: offlineTransport{}}
 	client := slack.NewClient(team)
-	err := client.WithCookieAuth()
+	client.WithHTTPClient(httpClient)
+
+	c, err := newWithCache(team, client, log)
+	if err != nil {
+		return nil, err
+	}
+	c.httpClient = httpClient
+
+	c.offline = true
+	return c, nil
+}
+
+func newWithCache(team string, client *slack.Client, log *log.Logger) (*SlackClient, error) {
+	dataDir, err := DataDir()
 	if err != nil {
 		return nil, err
 	}
 
-	c := &SlackClient{
-		cachePath: cachePath,
-		team:      team,
-		client:    client,
-		log:       log,
-		tz:        time.Now().Location(),
+	err = migrateLegacyCacheFile(dataDir, team)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate cache: %w", err)
 	}
 
-	return c, c.loadCache()
+	store, err := openJSONStore(jsonStorePath(teamDir(dataDir, team)), log)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SlackClient{
+		teamDir:    teamDir(dataDir, team),
+		team:       team,
+		store:      store,
+		userTTL:    DefaultCacheTTL,
+		channelTTL: DefaultCacheTTL,
+		client:     client,
+		log:        log,
+		tz:         time.Now().Location(),
+	}, nil
 }
 
 // Null produces a SlackClient suitable for testing that does not try to load
 // the Slack token or cookies from disk, and starts with an empty cache.
 func Null(team string, roundTripper http.RoundTripper) (*SlackClient, error) {
-	cacheFile, err := os.CreateTemp("", "gh-slack-cache")
+	dataDir, err := os.MkdirTemp("", "gh-slack")
 	if err != nil {
 		return nil, err
 	}
 
+	return newNull(dataDir, team, roundTripper), nil
+}
+
+// newNull is like Null, but keeps its cache in the given data directory.
+func newNull(dataDir, team string, roundTripper http.RoundTripper) *SlackClient {
+	httpClient := &http.Client{Transport: roundTripper}
 	client := slack.NewClient("test-team")
-	client.WithHTTPClient(&http.Client{Transport: roundTripper})
+	client.WithHTTPClient(httpClient)
+
+	logger := log.New(io.Discard, "", log.LstdFlags)
 
 	return &SlackClient{
-		team:      team,
-		client:    client,
-		cachePath: cacheFile.Name(),
-		tz:        time.UTC,
-	}, nil
+		team:       team,
+		client:     client,
+		httpClient: httpClient,
+		teamDir:    teamDir(dataDir, team),
+		store:      &jsonStore{path: jsonStorePath(teamDir(dataDir, team)), log: logger, cache: newCache()},
+		userTTL:    DefaultCacheTTL,
+		channelTTL: DefaultCacheTTL,
+		log:        logger,
+		tz:         time.UTC,
+	}
 }
 
+// UsernameForMessage returns the name of the message's author, in the client's
+// name style (see WithNameStyle).
 func (c *SlackClient) UsernameForMessage(message Message) (string, error) {
 	if message.User != "" {
-		return c.UsernameForID(message.User)
+		return c.NameForID(message.User)
 	}
 	if message.BotID != "" {
 		return fmt.Sprintf("bot %s", message.BotID), nil
@@ -202,6 +369,26 @@ func (c *SlackClient) post(path string, params map[string]string, msg *SendMessa
 	return c.API("POST", path, params, messageBytes)
 }
 
+// AuthTest returns the identity of the authenticated user and their team.
+func (c *SlackClient) AuthTest() (*AuthTestResponse, error) {
+	body, err := c.get("auth.test", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &AuthTestResponse{}
+	err = json.Unmarshal(body, response)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.Ok {
+		return nil, fmt.Errorf("auth.test response not OK: %s", body)
+	}
+
+	return response, nil
+}
+
 func (c *SlackClient) ChannelInfo(id string) (*Channel, error) {
 	body, err := c.get("conversations.info",
 		map[string]string{"channel": id})
@@ -215,6 +402,9 @@ func (c *SlackClient) ChannelInfo(id string) (*Channel, error) {
 		return nil, err
 	}
 
+	if channelInfoReponse.Error == "channel_not_found" {
+		return nil, fmt.Errorf("%w: no channel with id %q", errNotFound, id)
+	}
 	if !channelInfoReponse.Ok {
 		return nil, fmt.Errorf("conversations.info response not OK: %s", body)
 	}
@@ -223,26 +413,50 @@ func (c *SlackClient) ChannelInfo(id string) (*Channel, error) {
 }
 
 func (c *SlackClient) conversations() ([]Channel, error) {
-	fmt.Fprintf(os.Stderr, "Populating channel cache (this may take a while)...")
+	fmt.Fprintf(os.Stderr, "Populating channel cache (this may take a while, see 'gh-slack cache refresh --background')...")
+
+	channels, err := c.listConversations("public_channel,private_channel,mpim,im", true, func(n int) {
+		fmt.Fprintf(os.Stderr, "%d...", n)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(os.Stderr, "done!\n")
+	return channels, nil
+}
+
+// ListChannels returns every public and private channel visible to the
+// user, including archived channels if includeArchived is set. Unlike the
+// cache, this always fetches the whole list.
+func (c *SlackClient) ListChannels(includeArchived bool) ([]Channel, error) {
+	return c.listConversations("public_channel,private_channel", !includeArchived, func(int) {})
+}
+
+// ListUsers returns every user in the workspace, including bots and
+// deactivated users.
+func (c *SlackClient) ListUsers() ([]User, error) {
+	return c.users()
+}
 
+func (c *SlackClient) listConversations(types string, excludeArchived bool, progress func(int)) ([]Channel, error) {
 	channels := make([]Channel, 0, 1000)
-	conversations := &ConversationsResponse{}
+	cursor := ""
 	for {
-		c.log.Printf("Fetching conversations with cursor %q", conversations.ResponseMetadata.NextCursor)
+		c.log.Printf("Fetching conversations with cursor %q", cursor)
 		body, err := c.get("conversations.list",
 			map[string]string{
-				"cursor":           conversations.ResponseMetadata.NextCursor,
-				"exclude_archived": "true",
+				"cursor":           cursor,
+				"exclude_archived": strconv.FormatBool(excludeArchived),
 				"limit":            "1000",
-
-				// TODO: we might want to support DMs in the future
-				"types": "public_channel, private_channel",
+				"types":            types,
 			},
 		)
 		if err != nil {
 			return nil, err
 		}
 
+		conversations := &ConversationsResponse{}
 		if err = json.Unmarshal(body, conversations); err != nil {
 			return nil, err
 		}
@@ -252,14 +466,14 @@ func (c *SlackClient) conversations() ([]Channel, error) {
 		}
 
 		channels = append(channels, conversations.Channels...)
-		fmt.Fprintf(os.Stderr, "%d...", len(channels))
+		progress(len(channels))
 
-		if conversations.ResponseMetadata.NextCursor == "" {
+		cursor = conversations.ResponseMetadata.NextCursor
+		if cursor == "" {
 			break
 		}
 	}
 
-	fmt.Fprintf(os.Stderr, "done!\n")
 	return channels, nil
 }
 
@@ -294,28 +508,55 @@ func (c *SlackClient) users() ([]User, error) {
 	return users, nil
 }
 
-func (c *SlackClient) loadCache() error {
-	content, err := os.ReadFile(c.cachePath)
-	if errors.Is(err, os.ErrNotExist) {
-		return nil
-	} else if err != nil {
-		return err
+// History fetches the messages starting at startTimestamp. If thread is set, or
+// the starting message has replies, the messages come from that thread,
+// otherwise from the channel. When the archive is enabled (see UseArchive),
+// archived messages are used where possible.
+func (c *SlackClient) History(channelID string, startTimestamp string, thread string, limit int) (*HistoryResponse, error) {
+	if c.archive != nil {
+		return c.archivedHistory(channelID, startTimestamp, thread, limit)
+	}
+
+	var historyResponse *HistoryResponse
+	var err error
+	if thread != "" {
+		historyResponse, err = c.replies(channelID, thread, startTimestamp, limit)
+	} else {
+		historyResponse, err = c.replies(channelID, startTimestamp, "", limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// If thread was specified, then we are fetching only part of a thread and
+	// should remove the first message if it has a reply count as we don't want
+	// the root message.
+	if thread != "" && historyResponse.Messages[0].ReplyCount != 0 && len(historyResponse.Messages) > 1 {
+		historyResponse.Messages = historyResponse.Messages[1:]
+	}
+
+	if thread != "" || historyResponse.Messages[0].ReplyCount != 0 {
+		// Either we are deliberately fetching a subthread, or an entire thread.
+		return historyResponse, nil
 	}
 
-	return json.Unmarshal(content, &c.cache)
+	// Otherwise we read the general channel history
+	return c.channelHistory(channelID, startTimestamp, limit)
 }
 
-func (c *SlackClient) History(channelID string, startTimestamp string, thread string, limit int) (*HistoryResponse, error) {
+// replies fetches messages from a thread with conversations.replies. The root
+// message is always included. If oldest is set, only replies from then on
+// (inclusive) are fetched.
+func (c *SlackClient) replies(channelID, thread, oldest string, limit int) (*HistoryResponse, error) {
 	params := map[string]string{
 		"channel":   channelID,
-		"ts":        startTimestamp,
+		"ts":        thread,
 		"inclusive": "true",
 		"limit":     strconv.Itoa(limit),
 	}
 
-	if thread != "" {
-		params["ts"] = thread
-		params["oldest"] = startTimestamp
+	if oldest != "" {
+		params["oldest"] = oldest
 	}
 
 	body, err := c.API("POST", "conversations.replies", params, nil)
@@ -333,29 +574,23 @@ func (c *SlackClient) History(channelID string, startTimestamp string, thread st
 		return nil, fmt.Errorf("conversations.replies response not OK: %s", body)
 	}
 
-	// If thread was specified, then we are fetching only part of a thread and
-	// should remove the first message if it has a reply count as we don't want
-	// the root message.
-	if thread != "" && historyResponse.Messages[0].ReplyCount != 0 && len(historyResponse.Messages) > 1 {
-		historyResponse.Messages = historyResponse.Messages[1:]
-	}
-
-	if thread != "" || historyResponse.Messages[0].ReplyCount != 0 {
-		// Either we are deliberately fetching a subthread, or an entire thread.
-		return historyResponse, nil
-	}
+	return historyResponse, nil
+}
 
-	// Otherwise we read the general channel history
-	body, err = c.get("conversations.history",
+// channelHistory fetches channel messages from oldest (inclusive) with
+// conversations.history.
+func (c *SlackClient) channelHistory(channelID, oldest string, limit int) (*HistoryResponse, error) {
+	body, err := c.get("conversations.history",
 		map[string]string{
 			"channel":   channelID,
-			"oldest":    startTimestamp,
+			"oldest":    oldest,
 			"inclusive": "true",
 			"limit":     strconv.Itoa(limit)})
 	if err != nil {
 		return nil, err
 	}
 
+	historyResponse := &HistoryResponse{}
 	err = json.Unmarshal(body, historyResponse)
 	if err != nil {
 		return nil, err
@@ -369,102 +604,124 @@ func (c *SlackClient) History(channelID string, startTimestamp string, thread st
 	return historyResponse, nil
 }
 
-func (c *SlackClient) saveCache() error {
-	bs, err := json.Marshal(c.cache)
+// OpenConversation returns the ID of the direct message with a single user, or
+// the group DM with several users, creating it if necessary.
+func (c *SlackClient) OpenConversation(userIDs ...string) (string, error) {
+	body, err := c.API("POST", "conversations.open",
+		map[string]string{"users": strings.Join(userIDs, ",")}, nil)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	err = os.MkdirAll(path.Dir(c.cachePath), 0755)
+	response := &ChannelInfoResponse{}
+	err = json.Unmarshal(body, response)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	err = os.WriteFile(c.cachePath, bs, 0644)
-	if err != nil {
-		return err
+	if !response.Ok {
+		return "", fmt.Errorf("conversations.open response not OK: %s", body)
 	}
 
-	return nil
+	return response.Channel.ID, nil
 }
 
-func (c *SlackClient) UsernameForID(id string) (string, error) {
-	if name, ok := c.cache.Users[id]; ok {
-		return name, nil
-	}
+// ConversationMembers returns the IDs of the members of a conversation.
+func (c *SlackClient) ConversationMembers(channelID string) ([]string, error) {
+	members := []string{}
+	resp := &ConversationMembersResponse{}
+	for {
+		body, err := c.get("conversations.members", map[string]string{
+			"channel": channelID,
+			"cursor":  resp.ResponseMetadata.NextCursor,
+			"limit":   "1000",
+		})
+		if err != nil {
+			return nil, err
+		}
 
-	ur, err := c.users()
-	if err != nil {
-		return "", err
-	}
+		err = json.Unmarshal(body, resp)
+		if err != nil {
+			return nil, err
+		}
 
-	c.cache.Users = make(map[string]string)
-	for _, ch := range ur {
-		c.cache.Users[ch.ID] = ch.Name
-	}
+		if !resp.Ok {
+			return nil, fmt.Errorf("conversations.members response not OK: %s", body)
+		}
 
-	err = c.saveCache()
-	if err != nil {
-		return "", err
-	}
+		members = append(members, resp.Members...)
 
-	if name, ok := c.cache.Users[id]; ok {
-		return name, nil
+		if resp.ResponseMetadata.NextCursor == "" {
+			break
+		}
 	}
 
-	body, err := c.get("users.info", map[string]string{"user": id})
-	if err != nil {
-		return "", fmt.Errorf("no user with id %q: %w", id, err)
-	}
+	return members, nil
+}
 
-	user := &UsersInfoResponse{}
-	err = json.Unmarshal(body, user)
+// ConversationName returns a human-readable name for a conversation: "#name"
+// for channels, "@user" for direct messages, and a comma-separated list of
+// participants for group DMs.
+func (c *SlackClient) ConversationName(channelID string) (string, error) {
+	channel, err := c.conversationInfo(channelID)
 	if err != nil {
 		return "", err
 	}
 
-	if !user.Ok {
-		return "", errors.New("users.info response not OK")
+	if channel.Is_Im {
+		username, err := c.UsernameForID(channel.User)
+		if err != nil {
+			return "", err
+		}
+		return "@" + username, nil
 	}
 
-	c.cache.Users[id] = user.User.Name
-	err = c.saveCache()
-	if err != nil {
-		return "", err
+	if !channel.Is_Mpim {
+		return "#" + channel.Name, nil
 	}
 
-	return user.User.Name, nil
-}
-
-func (c *SlackClient) ChannelIDForName(name string) (string, error) {
-	if id, ok := c.cache.Channels[name]; ok {
-		return id, nil
+	if c.offline {
+		// The members are not cached.
+		return channel.Name, nil
 	}
 
-	channels, err := c.conversations()
+	members, err := c.ConversationMembers(channelID)
 	if err != nil {
 		return "", err
 	}
 
-	c.cache.Channels = make(map[string]string)
-	for _, ch := range channels {
-		if !ch.Is_Channel {
-			fmt.Fprintf(os.Stderr, "Skipping non-channel %q\n", ch.Name)
-			continue
+	names := make([]string, 0, len(members))
+	for _, member := range members {
+		username, err := c.UsernameForID(member)
+		if err != nil {
+			return "", err
 		}
-		c.cache.Channels[ch.Name] = ch.ID
+		names = append(names, "@"+username)
 	}
 
-	err = c.saveCache()
-	if err != nil {
-		return "", err
+	return strings.Join(names, ", "), nil
+}
+
+// conversationInfo returns the conversation with the given ID, caching it so
+// that it is available offline.
+func (c *SlackClient) conversationInfo(channelID string) (*Channel, error) {
+	if c.offline {
+		cached, err := c.store.Channel(channelID)
+		if err != nil {
+			return nil, err
+		}
+		if cached == nil {
+			return nil, fmt.Errorf("conversation %s is not cached: %w", channelID, ErrOffline)
+		}
+		return &cached.Channel, nil
 	}
 
-	if id, ok := c.cache.Channels[name]; ok {
-		return id, nil
+	channel, err := c.ChannelInfo(channelID)
+	if err != nil {
+		return nil, err
 	}
 
-	return "", fmt.Errorf("could not find any channel with name %q", name)
+	return channel, c.store.PutChannels(c.channelForCache(*channel, time.Now()))
 }
 
 func (c *SlackClient) GetLocation() *time.Location {
@@ -528,3 +785,42 @@ func (c *SlackClient) ConnectToRTM() (*RTMClient, error) {
 		slackClient: c,
 	}, err
 }
+
+func (c *SlackClient) ConnectToSocketMode() (*SocketModeClient, error) {
+	if c.appToken == "" {
+		return nil, fmt.Errorf("socket mode requires an app-level token (xapp-...) in %s", EnvSlackAppToken)
+	}
+	if !strings.HasPrefix(c.appToken, "xapp-") {
+		return nil, fmt.Errorf("%s must be an app-level token starting with \"xapp-\"", EnvSlackAppToken)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	appClient, _ := newAuthenticatedClient(c.team, &Credentials{Token: c.appToken})
+	response, err := appClient.API(ctx, "POST", "apps.connections.open", nil, []byte("{}"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Like rtm.connect, each URL returned here can only be used once.
+	connectResponse := &AppsConnectionsOpenResponse{}
+	err = json.Unmarshal(response, connectResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	if !connectResponse.Ok {
+		return nil, fmt.Errorf("apps.connections.open response not OK: %s", response)
+	}
+
+	socketConnection, _, err := websocket.Dial(ctx, connectResponse.URL, &websocket.DialOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SocketModeClient{
+		conn:        socketConnection,
+		slackClient: c,
+	}, nil
+}
@@ -0,0 +1,94 @@
+package slackclient_test
+
+import (
+	"testing"
+
+	"github.com/rneatherway/gh-slack/internal/mocks"
+	"github.com/rneatherway/gh-slack/internal/slackclient"
+)
+
+func TestConversationName(t *testing.T) {
+	tests := []struct {
+		name     string
+		info     string
+		expected string
+	}{
+		{
+			name:     "channel",
+			info:     `{"ok":true,"channel":{"id":"C1","name":"ops","is_channel":true}}`,
+			expected: "#ops",
+		},
+		{
+			name:     "direct message",
+			info:     `{"ok":true,"channel":{"id":"D1","is_im":true,"user":"U1"}}`,
+			expected: "@alice",
+		},
+		{
+			name:     "group DM",
+			info:     `{"ok":true,"channel":{"id":"G1","name":"mpdm-alice--bob-1","is_mpim":true}}`,
+			expected: "@alice, @bob",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockClient := &mocks.MockClient{}
+			mockClient.MockResponses(map[string]string{
+				"conversations.info":    test.info,
+				"conversations.members": `{"ok":true,"members":["U1","U2"]}`,
+				"users.info?user=U1":    `{"ok":true,"user":{"id":"U1","name":"alice"}}`,
+				"users.info?user=U2":    `{"ok":true,"user":{"id":"U2","name":"bob"}}`,
+			}, nil)
+
+			client, err := slackclient.Null("test", mockClient)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := client.ConversationName("C1")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if actual != test.expected {
+				t.Errorf("got %q, want %q", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestListChannelsIncludesArchived(t *testing.T) {
+	mockClient := &mocks.MockClient{}
+	mockClient.MockResponses(map[string]string{
+		"conversations.list?cursor=&exclude_archived=false&limit=1000&types=public_channel%2Cprivate_channel":     `{"ok":true,"channels":[{"id":"C1","name":"ops"}],"response_metadata":{"next_cursor":"next"}}`,
+		"conversations.list?cursor=next&exclude_archived=false&limit=1000&types=public_channel%2Cprivate_channel": `{"ok":true,"channels":[{"id":"C2","name":"old","is_archived":true}]}`,
+		"users.lookupByEmail?email=alice%40example.com":                                                           `{"ok":true,"user":{"id":"U1","name":"alice"}}`,
+		"users.lookupByEmail?email=nobody%40example.com":                                                          `{"ok":false,"error":"users_not_found"}`,
+	}, nil)
+
+	client, err := slackclient.Null("test", mockClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	channels, err := client.ListChannels(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(channels) != 2 || !channels[1].Is_Archived {
+		t.Errorf("unexpected channels %+v", channels)
+	}
+
+	user, err := client.UserForEmail("alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Name != "alice" {
+		t.Errorf("unexpected user %+v", user)
+	}
+
+	_, err = client.UserForEmail("nobody@example.com")
+	if err == nil {
+		t.Error("expected an error for an unknown email")
+	}
+}
@@ -0,0 +1,144 @@
+package slackclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/markdown"
+)
+
+// EventStream is a real-time connection to Slack. It is implemented by
+// RTMClient, using the legacy RTM API, and by SocketModeClient.
+type EventStream interface {
+	// NextEvent blocks until the next event arrives or ctx is done.
+	NextEvent(ctx context.Context) (*RTMEvent, error)
+	Close() error
+}
+
+// Transport selects how an EventStream connects to Slack.
+type Transport string
+
+const (
+	// TransportAuto uses Socket Mode if an app-level token is available, and
+	// the RTM API otherwise.
+	TransportAuto       Transport = "auto"
+	TransportRTM        Transport = "rtm"
+	TransportSocketMode Transport = "socket"
+)
+
+func ParseTransport(s string) (Transport, error) {
+	switch t := Transport(s); t {
+	case TransportAuto, TransportRTM, TransportSocketMode:
+		return t, nil
+	}
+
+	return "", fmt.Errorf("unknown transport %q, expected one of %q, %q or %q",
+		s, TransportAuto, TransportRTM, TransportSocketMode)
+}
+
+// ConnectToEventStream opens a real-time connection using the given transport.
+func (c *SlackClient) ConnectToEventStream(transport Transport) (EventStream, error) {
+	switch transport {
+	case TransportRTM:
+		return c.ConnectToRTM()
+	case TransportSocketMode:
+		return c.ConnectToSocketMode()
+	case TransportAuto:
+		if c.appToken != "" {
+			c.log.Println("App-level token found, connecting using Socket Mode")
+			return c.ConnectToSocketMode()
+		}
+		return c.ConnectToRTM()
+	}
+
+	return nil, fmt.Errorf("unknown transport %q", transport)
+}
+
+func trimAndPrint(w io.Writer, text string) {
+	s, err := markdown.Render(text)
+	if err != nil {
+		// This is a bit lazy, but the default configuration of the markdown
+		// renderer cannot fail.
+		panic(err)
+	}
+
+	s = strings.TrimRight(s, " \t\n")
+	if s == "" {
+		return
+	}
+
+	fmt.Fprintf(w, "%s\n", s)
+}
+
+func printMessage(w io.Writer, message *RTMEvent) {
+	trimAndPrint(w, message.Text)
+
+	for _, attachment := range message.Attachments {
+		trimAndPrint(w, attachment.Text)
+	}
+
+	for _, file := range message.Files {
+		trimAndPrint(w, file.Preview)
+	}
+}
+
+// nextResponseFromBot waits for a message from the bot in a given channel,
+// either newly posted or edited.
+func (c *SlackClient) nextResponseFromBot(ctx context.Context, stream EventStream, channelID string, bot *BotIdentity) (*MessageEvent, error) {
+	for {
+		event, err := stream.NextEvent(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		message, ok := event.AsMessage()
+		if !ok || message.Channel != channelID {
+			continue
+		}
+
+		if !message.IsResponse() {
+			c.log.Printf("Ignoring %s message event in %s", message.Kind, message.Channel)
+			continue
+		}
+
+		if c.correctUser(message.Message, bot) {
+			return message, nil
+		}
+	}
+}
+
+// ListenForMessagesFromBot listens for the first message from the bot in a
+// given channel and prints its contents. An edit of an earlier message from the
+// bot counts as a new message.
+func (c *SlackClient) ListenForMessagesFromBot(stream EventStream, channelID string, bot *BotIdentity, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	message, err := c.nextResponseFromBot(ctx, stream, channelID, bot)
+	if err != nil {
+		return err
+	}
+
+	printMessage(os.Stdout, message.Message)
+	return nil
+}
+
+// FollowMessagesFromBot prints every message from the bot in a given channel
+// to w as it arrives, until ctx is done or the stream fails.
+func (c *SlackClient) FollowMessagesFromBot(ctx context.Context, stream EventStream, channelID string, bot *BotIdentity, w io.Writer) error {
+	for {
+		message, err := c.nextResponseFromBot(ctx, stream, channelID, bot)
+		if err != nil {
+			return err
+		}
+
+		if message.Kind == MessageEdited {
+			trimAndPrint(w, "_(edited)_")
+		}
+		printMessage(w, message.Message)
+	}
+}
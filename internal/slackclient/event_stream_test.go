@@ -0,0 +1,76 @@
+package slackclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeStream replays the given events, then fails with err.
+type fakeStream struct {
+	events []string
+	err    error
+}
+
+func (s *fakeStream) NextEvent(ctx context.Context) (*RTMEvent, error) {
+	if len(s.events) == 0 {
+		return nil, s.err
+	}
+
+	event := &RTMEvent{}
+	err := json.Unmarshal([]byte(s.events[0]), event)
+	s.events = s.events[1:]
+	return event, err
+}
+
+func (s *fakeStream) Close() error {
+	return nil
+}
+
+func TestFollowMessagesFromBot(t *testing.T) {
+	client, err := Null("test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	dropped := errors.New("connection dropped")
+	stream := &fakeStream{
+		events: []string{
+			`{"type":"hello"}`,
+			`{"type":"message","channel":"C1","user":"U2","text":"from someone else","ts":"1.1"}`,
+			`{"type":"message","channel":"C2","user":"U1","text":"in another channel","ts":"1.2"}`,
+			`{"type":"message","channel":"C1","user":"U1","text":"deployed","ts":"1.3",
+				"attachments":[{"text":"all green"}]}`,
+			`{"type":"message","subtype":"message_changed","hidden":true,"channel":"C1","ts":"2.1",
+				"message":{"type":"message","user":"U1","text":"rolled back","ts":"1.3"},
+				"previous_message":{"type":"message","user":"U1","text":"deployed","ts":"1.3"}}`,
+			`{"type":"message","subtype":"message_deleted","hidden":true,"channel":"C1","ts":"2.2","deleted_ts":"1.3",
+				"previous_message":{"type":"message","user":"U1","text":"rolled back","ts":"1.3"}}`,
+		},
+		err: dropped,
+	}
+
+	var out strings.Builder
+	err = client.FollowMessagesFromBot(context.Background(), stream, "C1", &BotIdentity{UserID: "U1"}, &out)
+	if !errors.Is(err, dropped) {
+		t.Errorf("expected the stream's error, got %v", err)
+	}
+
+	actual := out.String()
+	for _, expected := range []string{"deployed", "all green", "(edited)", "rolled back"} {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("expected %q in the output:\n%s", expected, actual)
+		}
+	}
+	for _, unexpected := range []string{"someone else", "another channel"} {
+		if strings.Contains(actual, unexpected) {
+			t.Errorf("expected no %q in the output:\n%s", unexpected, actual)
+		}
+	}
+	if strings.Index(actual, "deployed") > strings.Index(actual, "rolled back") {
+		t.Errorf("expected the messages in the order they arrived:\n%s", actual)
+	}
+}
@@ -0,0 +1,119 @@
+package slackclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// exportPageSize is the number of messages requested per page when exporting.
+// Slack recommends no more than 200.
+const exportPageSize = "200"
+
+// RawPage is a page of messages kept exactly as Slack returned them, so that
+// an export has every field, not just those in Message.
+type RawPage struct {
+	CursorResponseMetadata
+	Ok       bool
+	Error    string
+	HasMore  bool `json:"has_more"`
+	Messages []json.RawMessage
+}
+
+// ErrInvalidCursor is returned for a page whose cursor Slack no longer
+// accepts, for example because it has expired.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// HistoryPage fetches a page of a channel's history before latest (exclusive,
+// and may be empty to start from the newest message), newest first. Pass the
+// previous page's next cursor, with the same latest, to fetch the following
+// page.
+func (c *SlackClient) HistoryPage(channelID, latest, cursor string) (*RawPage, error) {
+	return c.rawPage("conversations.history", map[string]string{
+		"channel": channelID,
+		"latest":  latest,
+		"cursor":  cursor,
+		"limit":   exportPageSize,
+	})
+}
+
+// RepliesPage fetches a page of a thread, including its root message, oldest
+// first.
+func (c *SlackClient) RepliesPage(channelID, thread, cursor string) (*RawPage, error) {
+	return c.rawPage("conversations.replies", map[string]string{
+		"channel": channelID,
+		"ts":      thread,
+		"cursor":  cursor,
+		"limit":   exportPageSize,
+	})
+}
+
+func (c *SlackClient) rawPage(method string, params map[string]string) (*RawPage, error) {
+	c.log.Printf("Fetching %s with cursor %q", method, params["cursor"])
+	body, err := c.get(method, params)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &RawPage{}
+	err = json.Unmarshal(body, page)
+	if err != nil {
+		return nil, err
+	}
+
+	if page.Error == "invalid_cursor" {
+		return nil, fmt.Errorf("%s: %w", method, ErrInvalidCursor)
+	}
+	if !page.Ok {
+		return nil, fmt.Errorf("%s response not OK: %s", method, body)
+	}
+
+	return page, nil
+}
+
+// isSlackHost reports whether host belongs to Slack, so that it may be sent
+// the client's credentials.
+func isSlackHost(host string) bool {
+	for _, domain := range []string{"slack.com", "slack-gov.com"} {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// DownloadFile writes the content of a file shared in Slack, from its
+// url_private or url_private_download, to w.
+func (c *SlackClient) DownloadFile(fileURL string, w io.Writer) error {
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return err
+	}
+
+	if u.Scheme != "https" || !isSlackHost(u.Hostname()) {
+		return fmt.Errorf("refusing to send credentials to %q, which is not a Slack URL", fileURL)
+	}
+
+	req, err := http.NewRequest("GET", fileURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: %s", fileURL, resp.Status)
+	}
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
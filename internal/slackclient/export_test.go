@@ -0,0 +1,56 @@
+package slackclient
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDownloadFileOnlySendsCredentialsToSlack(t *testing.T) {
+	var requests []*http.Request
+	client := newNull(t.TempDir(), "test", roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests = append(requests, req)
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("content"))}, nil
+	}))
+	client.token = "xoxp-token"
+
+	b := &strings.Builder{}
+	err := client.DownloadFile("https://files.slack.com/files-pri/T1-F1/download/notes.txt", b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "content" || requests[0].Header.Get("Authorization") != "Bearer xoxp-token" {
+		t.Errorf("unexpected download %q with %v", b.String(), requests[0].Header)
+	}
+
+	for _, fileURL := range []string{
+		"https://files.slack.com.example.com/notes.txt",
+		"http://files.slack.com/notes.txt",
+		"https://example.com/notes.txt",
+	} {
+		err = client.DownloadFile(fileURL, b)
+		if err == nil {
+			t.Errorf("expected %s to be refused", fileURL)
+		}
+	}
+	if len(requests) != 1 {
+		t.Errorf("expected only the Slack URL to be requested, got %d requests", len(requests))
+	}
+}
+
+func TestDownloadFileFromGovSlack(t *testing.T) {
+	var host string
+	client := newNull(t.TempDir(), "test", govSlackTransport{roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		host = req.URL.Host
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("content"))}, nil
+	})})
+
+	err := client.DownloadFile("https://files.slack-gov.com/files-pri/T1-F1/download/notes.txt", io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host != "files.slack-gov.com" {
+		t.Errorf("expected the download to go to files.slack-gov.com, got %s", host)
+	}
+}
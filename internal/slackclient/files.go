@@ -0,0 +1,70 @@
+package slackclient
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// updateFile replaces the contents of a file with the result of applying
+// change to its current contents (nil if it doesn't exist). The file is locked
+// throughout, so that concurrent updates from other processes are not lost,
+// and replaced atomically, so that readers never see a partial file.
+func updateFile(filePath string, change func(content []byte) ([]byte, error)) (err error) {
+	err = os.MkdirAll(path.Dir(filePath), 0755)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := lockFile(filePath + ".lock")
+	if err != nil {
+		return fmt.Errorf("failed to lock %q: %w", filePath, err)
+	}
+	defer func() {
+		if unlockErr := unlock(); err == nil {
+			err = unlockErr
+		}
+	}()
+
+	content, err := os.ReadFile(filePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	content, err = change(content)
+	if err != nil {
+		return err
+	}
+
+	return WriteFileAtomic(filePath, content)
+}
+
+// WriteFileAtomic writes a file by renaming a temporary file over it, so that
+// readers never see a partial file, even if writing it is interrupted.
+func WriteFileAtomic(filePath string, content []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(content)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(f.Name(), 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), filePath)
+}
@@ -0,0 +1,185 @@
+package slackclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	userIDRE = regexp.MustCompile("^[UW][A-Z0-9]{8,}$")
+	botIDRE  = regexp.MustCompile("^B[A-Z0-9]{8,}$")
+	appIDRE  = regexp.MustCompile("^A[A-Z0-9]{8,}$")
+
+	errNotFound = errors.New("not found")
+)
+
+type BotsInfoResponse struct {
+	Ok    bool
+	Error string
+	Bot   BotProfile
+}
+
+// BotIdentity identifies the sender of messages to wait for. Messages match if
+// they match any of the fields that are set.
+type BotIdentity struct {
+	UserID string
+	BotID  string
+	AppID  string
+
+	// Name is compared case-insensitively against bot profile names, legacy
+	// bot usernames and user names. It is only set when the identity could not
+	// be resolved to an ID.
+	Name string
+}
+
+func (b *BotIdentity) String() string {
+	var parts []string
+	for _, part := range []struct{ label, value string }{
+		{"user", b.UserID},
+		{"bot", b.BotID},
+		{"app", b.AppID},
+		{"name", b.Name},
+	} {
+		if part.value != "" {
+			parts = append(parts, fmt.Sprintf("%s %s", part.label, part.value))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ResolveBotIdentity interprets key as a user ID, bot ID or app ID, looking up
+// the related IDs with users.info or bots.info, and otherwise as a name.
+func (c *SlackClient) ResolveBotIdentity(key string) (*BotIdentity, error) {
+	switch {
+	case userIDRE.MatchString(key):
+		user, err := c.userInfo(key)
+		if errors.Is(err, errNotFound) {
+			// Users outside the workspace, such as external collaborators,
+			// can't be looked up, but their messages still carry the ID.
+			c.log.Printf("No user %q found, matching it as a user ID or name", key)
+			return &BotIdentity{UserID: key, Name: key}, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		return &BotIdentity{UserID: user.ID, BotID: user.Profile.BotID}, nil
+	case botIDRE.MatchString(key):
+		bot, err := c.BotInfo(key)
+		if errors.Is(err, errNotFound) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		return &BotIdentity{UserID: bot.UserID, BotID: bot.ID, AppID: bot.AppID}, nil
+	case appIDRE.MatchString(key):
+		// There is no API to look up an app's bot by app ID, but messages from
+		// apps include the app ID in their bot profile.
+		return &BotIdentity{AppID: key}, nil
+	}
+
+	c.log.Printf("Treating %q as a name", key)
+	return &BotIdentity{Name: key}, nil
+}
+
+// BotInfo returns the profile of the bot with the given ID, from the cache if
+// possible and otherwise with bots.info.
+func (c *SlackClient) BotInfo(id string) (*BotProfile, error) {
+	cached, err := c.store.Bot(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached != nil && isFresh(cached.FetchedAt, c.userTTL) {
+		return &cached.BotProfile, nil
+	}
+
+	body, err := c.get("bots.info", map[string]string{"bot": id})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &BotsInfoResponse{}
+	err = json.Unmarshal(body, response)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Error == "bot_not_found" {
+		return nil, fmt.Errorf("%w: no bot with id %q", errNotFound, id)
+	}
+
+	if !response.Ok {
+		return nil, fmt.Errorf("bots.info response not OK: %s", body)
+	}
+
+	err = c.store.PutBot(CachedBot{BotProfile: response.Bot, FetchedAt: time.Now()})
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.Bot, nil
+}
+
+// correctUser checks if the message is sent by the bot/user that we are waiting
+// for. Any ID set in the identity must match exactly. Otherwise the identity's
+// name is compared (case-insensitively) against:
+//   - The bot profile's name
+//   - The username of a legacy bot_message
+//   - The name of a legacy bot, looked up by its bot ID
+//   - The user's name
+func (c *SlackClient) correctUser(message *RTMEvent, bot *BotIdentity) bool {
+	if bot.UserID != "" && message.User == bot.UserID {
+		return true
+	}
+
+	if bot.BotID != "" && (message.BotID == bot.BotID || message.BotProfile.ID == bot.BotID) {
+		return true
+	}
+
+	if bot.AppID != "" && message.BotProfile.AppID == bot.AppID {
+		return true
+	}
+
+	if bot.Name == "" {
+		return false
+	}
+
+	if strings.EqualFold(message.BotProfile.Name, bot.Name) {
+		return true
+	}
+
+	if message.Subtype == SubtypeBotMessage && strings.EqualFold(message.Username, bot.Name) {
+		return true
+	}
+
+	if message.BotID != "" && message.BotProfile.Name == "" {
+		profile, err := c.BotInfo(message.BotID)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		} else if strings.EqualFold(profile.Name, bot.Name) {
+			return true
+		}
+	}
+
+	if message.User == "" {
+		return false
+	}
+
+	// It would be nice to just convert the name to an ID up front, but the
+	// Slack API only supports that by listing every user in the workspace, and
+	// not at all if the bot is not a member of the team (an outside
+	// collaborator). So instead we look up each sender individually.
+	user, err := c.UsernameForID(message.User)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+
+	return strings.EqualFold(user, bot.Name)
+}
@@ -0,0 +1,84 @@
+package slackclient_test
+
+import (
+	"testing"
+
+	"github.com/rneatherway/gh-slack/internal/mocks"
+	"github.com/rneatherway/gh-slack/internal/slackclient"
+)
+
+func TestResolveBotIdentity(t *testing.T) {
+	tests := []struct {
+		key      string
+		expected slackclient.BotIdentity
+		calls    []string
+	}{
+		{
+			key:      "U01ABCDEFGH",
+			expected: slackclient.BotIdentity{UserID: "U01ABCDEFGH", BotID: "B01ABCDEFGH"},
+			calls:    []string{"users.info"},
+		},
+		{
+			key:      "U09EXTERNAL",
+			expected: slackclient.BotIdentity{UserID: "U09EXTERNAL", Name: "U09EXTERNAL"},
+			calls:    []string{"users.info"},
+		},
+		{
+			key:      "B01ABCDEFGH",
+			expected: slackclient.BotIdentity{UserID: "U01ABCDEFGH", BotID: "B01ABCDEFGH", AppID: "A01ABCDEFGH"},
+			calls:    []string{"bots.info"},
+		},
+		{
+			key:      "B09NOTABOT",
+			expected: slackclient.BotIdentity{Name: "B09NOTABOT"},
+			calls:    []string{"bots.info"},
+		},
+		{
+			key:      "A01ABCDEFGH",
+			expected: slackclient.BotIdentity{AppID: "A01ABCDEFGH"},
+		},
+		{
+			key:      "robot",
+			expected: slackclient.BotIdentity{Name: "robot"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			mockClient := &mocks.MockClient{}
+			var calls []string
+			bot := `{"ok":true,"bot":{"id":"B01ABCDEFGH","name":"robot","app_id":"A01ABCDEFGH","user_id":"U01ABCDEFGH"}}`
+			if test.key == "B09NOTABOT" {
+				bot = `{"ok":false,"error":"bot_not_found"}`
+			}
+			mockClient.MockResponses(map[string]string{
+				"users.info":                  `{"ok":true,"user":{"id":"U01ABCDEFGH","name":"robot","is_bot":true,"profile":{"bot_id":"B01ABCDEFGH"}}}`,
+				"users.info?user=U09EXTERNAL": `{"ok":false,"error":"user_not_found"}`,
+				"bots.info":                   bot,
+			}, &calls)
+
+			client, err := slackclient.Null("test", mockClient)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := client.ResolveBotIdentity(test.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if *actual != test.expected {
+				t.Errorf("got %+v, want %+v", *actual, test.expected)
+			}
+
+			if len(calls) != len(test.calls) {
+				t.Fatalf("got API calls %q, want %q", calls, test.calls)
+			}
+			for i := range calls {
+				if calls[i] != test.calls[i] {
+					t.Errorf("got API calls %q, want %q", calls, test.calls)
+				}
+			}
+		})
+	}
+}
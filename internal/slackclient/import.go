@@ -0,0 +1,56 @@
+package slackclient
+
+import (
+	"path"
+	"slices"
+	"time"
+)
+
+// ImportUsers stores users read from an export in the cache.
+func (c *SlackClient) ImportUsers(users []User) error {
+	fetchedAt := time.Now()
+	cached := make([]CachedUser, len(users))
+	for i, user := range users {
+		cached[i] = CachedUser{User: user, FetchedAt: fetchedAt}
+	}
+
+	return c.store.PutUsers(cached...)
+}
+
+// ImportChannels stores channels read from an export in the cache. Direct
+// messages are named after the other user, so their users must be imported
+// first.
+func (c *SlackClient) ImportChannels(channels []Channel) error {
+	fetchedAt := time.Now()
+	cached := make([]CachedChannel, 0, len(channels))
+	for _, ch := range channels {
+		cached = append(cached, c.channelForCache(ch, fetchedAt))
+	}
+
+	return c.store.PutChannels(cached...)
+}
+
+// ImportHistory stores messages read from an export in the message archive,
+// as the history of a channel or, if thread is set, of a thread (including its
+// root message). The archive is then complete from the first message to the
+// last, so History can read them offline.
+func (c *SlackClient) ImportHistory(channelID, thread string, messages []Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	messages = slices.Clone(messages)
+	slices.SortFunc(messages, func(a, b Message) int {
+		return compareTimestamps(a.Ts, b.Ts)
+	})
+
+	archive := c.archive
+	if archive == nil {
+		archive = &messageArchive{dir: path.Join(c.teamDir, "archive")}
+	}
+
+	return archive.add(channelID, thread, messages, tsSpan{
+		Oldest: messages[0].Ts,
+		Latest: messages[len(messages)-1].Ts,
+	})
+}
@@ -0,0 +1,279 @@
+package slackclient
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"maps"
+	"os"
+	"path"
+	"sync"
+)
+
+// cacheVersion is the version of the cache file format written by jsonStore.
+const cacheVersion = 2
+
+// legacyCache is the cache format before version 2, which mapped channel names
+// to IDs and user IDs to names, without recording when they were fetched.
+type legacyCache struct {
+	Channels map[string]string
+	Users    map[string]string
+	Bots     map[string]BotProfile
+}
+
+// jsonStore keeps the whole cache in memory, and writes it to a single JSON
+// file on every change. Several processes may share the file: each change is
+// made while holding a lock on the file, and is applied to the latest contents
+// on disk rather than overwriting them with our (possibly stale) copy.
+type jsonStore struct {
+	path string
+	log  *log.Logger
+
+	mu    sync.Mutex
+	cache Cache
+}
+
+func jsonStorePath(teamDir string) string {
+	return path.Join(teamDir, "cache.json")
+}
+
+func openJSONStore(path string, log *log.Logger) (*jsonStore, error) {
+	s := &jsonStore{path: path, log: log}
+	return s, s.load()
+}
+
+// load replaces the in-memory cache with the contents of the file. The file
+// is only ever replaced by renaming, so this doesn't need the lock.
+func (s *jsonStore) load() error {
+	content, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		content = nil
+	} else if err != nil {
+		return err
+	}
+
+	return s.parse(content)
+}
+
+func (s *jsonStore) parse(content []byte) error {
+	s.cache = newCache()
+	if content == nil {
+		return nil
+	}
+
+	var header struct {
+		Version int `json:"version"`
+	}
+	err := json.Unmarshal(content, &header)
+	if err != nil {
+		s.log.Printf("Ignoring unreadable cache %q: %s", s.path, err)
+		return nil
+	}
+
+	switch header.Version {
+	case cacheVersion:
+		err = json.Unmarshal(content, &s.cache)
+		if err != nil {
+			return err
+		}
+	case 0:
+		s.migrateLegacyCache(content)
+	default:
+		s.log.Printf("Ignoring cache %q with unknown version %d", s.path, header.Version)
+		return nil
+	}
+
+	// Older files may be missing some maps.
+	if s.cache.Channels == nil {
+		s.cache.Channels = map[string]CachedChannel{}
+	}
+	if s.cache.Users == nil {
+		s.cache.Users = map[string]CachedUser{}
+	}
+	if s.cache.Bots == nil {
+		s.cache.Bots = map[string]CachedBot{}
+	}
+
+	return nil
+}
+
+// migrateLegacyCache converts a version 1 cache. The entries have no fetch
+// time, so they will be refreshed the first time they are used.
+func (s *jsonStore) migrateLegacyCache(content []byte) {
+	legacy := legacyCache{}
+	err := json.Unmarshal(content, &legacy)
+	if err != nil {
+		s.log.Printf("Ignoring unreadable cache %q: %s", s.path, err)
+		return
+	}
+
+	for name, id := range legacy.Channels {
+		s.cache.Channels[id] = CachedChannel{Channel: Channel{ID: id, Name: name}}
+	}
+	for id, name := range legacy.Users {
+		s.cache.Users[id] = CachedUser{User: User{ID: id, Name: name}}
+	}
+	for id, bot := range legacy.Bots {
+		s.cache.Bots[id] = CachedBot{BotProfile: bot}
+	}
+}
+
+// update applies a change to the latest contents of the cache file and writes
+// the result back, so that changes made by other processes in the meantime are
+// kept.
+func (s *jsonStore) update(change func(cache *Cache)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return updateFile(s.path, func(content []byte) ([]byte, error) {
+		err := s.parse(content)
+		if err != nil {
+			return nil, err
+		}
+
+		change(&s.cache)
+		return json.Marshal(s.cache)
+	})
+}
+
+func (s *jsonStore) Path() string {
+	return s.path
+}
+
+func (s *jsonStore) Team() (string, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.cache.TeamID, s.cache.EnterpriseID, nil
+}
+
+func (s *jsonStore) SetTeam(teamID, enterpriseID string) error {
+	return s.update(func(cache *Cache) {
+		cache.TeamID = teamID
+		cache.EnterpriseID = enterpriseID
+	})
+}
+
+func (s *jsonStore) User(id string) (*CachedUser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user, ok := s.cache.Users[id]; ok {
+		return &user, nil
+	}
+	return nil, nil
+}
+
+func (s *jsonStore) UserByName(name string) (*CachedUser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, user := range s.cache.Users {
+		if user.Name == name {
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+// PutUsers adds users to the cache, unless another process has cached a more
+// recent copy in the meantime. PutChannels and PutBot do the same.
+func (s *jsonStore) PutUsers(users ...CachedUser) error {
+	return s.update(func(cache *Cache) {
+		for _, user := range users {
+			if existing, ok := cache.Users[user.ID]; !ok || !existing.FetchedAt.After(user.FetchedAt) {
+				cache.Users[user.ID] = user
+			}
+		}
+	})
+}
+
+func (s *jsonStore) Channel(id string) (*CachedChannel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if channel, ok := s.cache.Channels[id]; ok {
+		return &channel, nil
+	}
+	return nil, nil
+}
+
+func (s *jsonStore) ChannelByName(name string) (*CachedChannel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, channel := range s.cache.Channels {
+		if channel.Name == name {
+			return &channel, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *jsonStore) PutChannels(channels ...CachedChannel) error {
+	return s.update(func(cache *Cache) {
+		for _, channel := range channels {
+			if existing, ok := cache.Channels[channel.ID]; !ok || !existing.FetchedAt.After(channel.FetchedAt) {
+				cache.Channels[channel.ID] = channel
+			}
+		}
+	})
+}
+
+func (s *jsonStore) DeleteChannel(id string) error {
+	return s.update(func(cache *Cache) {
+		delete(cache.Channels, id)
+	})
+}
+
+func (s *jsonStore) Bot(id string) (*CachedBot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if bot, ok := s.cache.Bots[id]; ok {
+		return &bot, nil
+	}
+	return nil, nil
+}
+
+func (s *jsonStore) PutBot(bot CachedBot) error {
+	return s.update(func(cache *Cache) {
+		if existing, ok := cache.Bots[bot.ID]; !ok || !existing.FetchedAt.After(bot.FetchedAt) {
+			cache.Bots[bot.ID] = bot
+		}
+	})
+}
+
+// Contents returns a copy of the cache, which can be used without holding the
+// lock.
+func (s *jsonStore) Contents() (Cache, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cache := s.cache
+	cache.Users = maps.Clone(s.cache.Users)
+	cache.Channels = maps.Clone(s.cache.Channels)
+	cache.Bots = maps.Clone(s.cache.Bots)
+	return cache, nil
+}
+
+func (s *jsonStore) Clear(users, channels bool) error {
+	return s.update(func(cache *Cache) {
+		if users && channels {
+			*cache = newCache()
+			return
+		}
+
+		if users {
+			cache.Users = map[string]CachedUser{}
+			cache.Bots = map[string]CachedBot{}
+		}
+		if channels {
+			cache.Channels = map[string]CachedChannel{}
+		}
+	})
+}
+
+func (s *jsonStore) Close() error {
+	return nil
+}
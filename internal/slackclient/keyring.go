@@ -0,0 +1,307 @@
+package slackclient
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+)
+
+// keyring stores credentials for each team. get returns errNoCredentials if
+// none are stored.
+type keyring interface {
+	String() string
+	get(team string) (*Credentials, error)
+	set(team string, credentials *Credentials) error
+	remove(team string) error
+}
+
+// systemKeyring is the operating system's keyring, if it has one that we can
+// use. It is replaced in tests.
+var systemKeyring = openSystemKeyring
+
+// keyrings returns the keyrings to use, in order of preference. The encrypted
+// file is always included, so that credentials stored there while the system
+// keyring was unavailable can still be found.
+func keyrings() ([]keyring, error) {
+	file, err := openFileKeyring()
+	if err != nil {
+		return nil, err
+	}
+
+	system, err := systemKeyring()
+	if err != nil {
+		return []keyring{file}, nil
+	}
+
+	return []keyring{system, file}, nil
+}
+
+// StoreCredentials stores the team's credentials in the system keyring, or an
+// encrypted file if there is none, and returns a description of where they
+// were stored.
+func StoreCredentials(team string, credentials *Credentials) (string, error) {
+	stores, err := keyrings()
+	if err != nil {
+		return "", err
+	}
+
+	var errs []error
+	for _, store := range stores {
+		err := store.set(team, credentials)
+		if err == nil {
+			return store.String(), nil
+		}
+		errs = append(errs, fmt.Errorf("failed to store credentials in %s: %w", store, err))
+	}
+
+	return "", errors.Join(errs...)
+}
+
+// StoredCredentials returns the team's stored credentials, or an error
+// wrapping ErrNotLoggedIn if there are none.
+func StoredCredentials(team string) (*Credentials, error) {
+	stores, err := keyrings()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, store := range stores {
+		credentials, err := store.get(team)
+		if errors.Is(err, errNoCredentials) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read credentials from %s: %w", store, err)
+		}
+
+		credentials.Source = store.String()
+		return credentials, nil
+	}
+
+	return nil, fmt.Errorf("%w to team %q", ErrNotLoggedIn, team)
+}
+
+// DeleteStoredCredentials removes the team's credentials from every keyring.
+// It returns ErrNotLoggedIn if there were none.
+func DeleteStoredCredentials(team string) error {
+	stores, err := keyrings()
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, store := range stores {
+		err := store.remove(team)
+		if errors.Is(err, errNoCredentials) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to remove credentials from %s: %w", store, err)
+		}
+		found = true
+	}
+
+	if !found {
+		return fmt.Errorf("%w to team %q", ErrNotLoggedIn, team)
+	}
+	return nil
+}
+
+// ErrNotLoggedIn is returned when no credentials are stored for a team.
+var ErrNotLoggedIn = errors.New("not logged in")
+
+func keyringCredentials(team string, _ AuthOptions) (*Credentials, error) {
+	credentials, err := StoredCredentials(team)
+	if errors.Is(err, ErrNotLoggedIn) {
+		return nil, fmt.Errorf("%w, run \"gh-slack auth login\"", errNoCredentials)
+	}
+	return credentials, err
+}
+
+// fileKeyring keeps credentials in a file encrypted with AES-GCM, using a
+// random key stored separately in the user's configuration directory. This
+// keeps the credentials out of backups and copies of the data directory, but
+// unlike a system keyring does not protect them from other programs run by
+// the same user.
+type fileKeyring struct {
+	path    string
+	keyPath string
+}
+
+func openFileKeyring() (*fileKeyring, error) {
+	dataDir, err := DataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileKeyring{
+		path:    path.Join(dataDir, "credentials.enc"),
+		keyPath: path.Join(configDir, "gh-slack", "credentials.key"),
+	}, nil
+}
+
+func (k *fileKeyring) String() string {
+	return "the encrypted file " + k.path
+}
+
+// key returns the encryption key, creating it if create is set.
+func (k *fileKeyring) key(create bool) ([]byte, error) {
+	key, err := os.ReadFile(k.keyPath)
+	if errors.Is(err, os.ErrNotExist) && create {
+		key = make([]byte, 32)
+		_, err = rand.Read(key)
+		if err != nil {
+			return nil, err
+		}
+
+		err = os.MkdirAll(path.Dir(k.keyPath), 0700)
+		if err != nil {
+			return nil, err
+		}
+
+		// O_EXCL so that a key created concurrently is never overwritten.
+		file, err := os.OpenFile(k.keyPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if errors.Is(err, os.ErrExist) {
+			return k.key(false)
+		} else if err != nil {
+			return nil, err
+		}
+
+		_, err = file.Write(key)
+		return key, errors.Join(err, file.Close())
+	} else if err != nil {
+		return nil, err
+	}
+
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid key in %s", k.keyPath)
+	}
+	return key, nil
+}
+
+func (k *fileKeyring) cipher(create bool) (cipher.AEAD, error) {
+	key, err := k.key(create)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func (k *fileKeyring) decrypt(aead cipher.AEAD, content []byte) (map[string]*Credentials, error) {
+	teams := map[string]*Credentials{}
+	if len(content) == 0 {
+		return teams, nil
+	}
+
+	if len(content) < aead.NonceSize() {
+		return nil, errors.New("file is truncated")
+	}
+
+	nonce, sealed := content[:aead.NonceSize()], content[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt, the key in %s may have changed: %w", k.keyPath, err)
+	}
+
+	return teams, json.Unmarshal(plaintext, &teams)
+}
+
+func (k *fileKeyring) encrypt(aead cipher.AEAD, teams map[string]*Credentials) ([]byte, error) {
+	plaintext, err := json.Marshal(teams)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (k *fileKeyring) get(team string) (*Credentials, error) {
+	content, err := os.ReadFile(k.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errNoCredentials
+	} else if err != nil {
+		return nil, err
+	}
+
+	aead, err := k.cipher(false)
+	if err != nil {
+		return nil, err
+	}
+
+	teams, err := k.decrypt(aead, content)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, ok := teams[team]
+	if !ok {
+		return nil, errNoCredentials
+	}
+	return credentials, nil
+}
+
+// update changes the stored credentials, returning errNoCredentials if the
+// change reports that there was nothing to do.
+func (k *fileKeyring) update(create bool, change func(map[string]*Credentials) bool) error {
+	aead, err := k.cipher(create)
+	if errors.Is(err, os.ErrNotExist) {
+		return errNoCredentials
+	} else if err != nil {
+		return err
+	}
+
+	changed := false
+	err = updateFile(k.path, func(content []byte) ([]byte, error) {
+		teams, err := k.decrypt(aead, content)
+		if err != nil {
+			return nil, err
+		}
+
+		changed = change(teams)
+		return k.encrypt(aead, teams)
+	})
+	if err != nil {
+		return err
+	}
+
+	if !changed {
+		return errNoCredentials
+	}
+	return os.Chmod(k.path, 0600)
+}
+
+func (k *fileKeyring) set(team string, credentials *Credentials) error {
+	return k.update(true, func(teams map[string]*Credentials) bool {
+		teams[team] = credentials
+		return true
+	})
+}
+
+func (k *fileKeyring) remove(team string) error {
+	return k.update(false, func(teams map[string]*Credentials) bool {
+		_, ok := teams[team]
+		delete(teams, team)
+		return ok
+	})
+}
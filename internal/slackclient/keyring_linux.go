@@ -0,0 +1,122 @@
+//go:build linux
+
+package slackclient
+
+import (
+	"encoding/json"
+	"errors"
+
+	"r00t2.io/gosecret"
+)
+
+// secretServiceKeyring stores credentials using the Secret Service API, as
+// provided by GNOME Keyring and KWallet.
+type secretServiceKeyring struct {
+	service *gosecret.Service
+}
+
+func openSystemKeyring() (keyring, error) {
+	service, err := gosecret.NewService()
+	if err != nil {
+		return nil, err
+	}
+
+	return &secretServiceKeyring{service}, nil
+}
+
+func (k *secretServiceKeyring) String() string {
+	return "the system keyring"
+}
+
+func secretAttributes(team string) map[string]string {
+	return map[string]string{
+		"application": "gh-slack",
+		"team":        team,
+	}
+}
+
+// items returns the team's items, unlocking them if necessary.
+func (k *secretServiceKeyring) items(team string) ([]*gosecret.Item, error) {
+	unlocked, locked, err := k.service.SearchItems(secretAttributes(team))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range locked {
+		err = k.service.Unlock(item)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = item.GetSecret(k.service.Session)
+		if err != nil {
+			return nil, err
+		}
+		unlocked = append(unlocked, item)
+	}
+
+	return unlocked, nil
+}
+
+func (k *secretServiceKeyring) get(team string) (*Credentials, error) {
+	items, err := k.items(team)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(items) {
+	case 0:
+		return nil, errNoCredentials
+	case 1:
+		credentials := &Credentials{}
+		return credentials, json.Unmarshal(items[0].Secret.Value, credentials)
+	default:
+		return nil, errors.New("multiple items found")
+	}
+}
+
+func (k *secretServiceKeyring) set(team string, credentials *Credentials) error {
+	value, err := json.Marshal(credentials)
+	if err != nil {
+		return err
+	}
+
+	collection, err := k.service.GetCollection("default")
+	if err != nil {
+		return err
+	}
+
+	locked, err := collection.Locked()
+	if err != nil {
+		return err
+	}
+	if locked {
+		err = k.service.Unlock(collection)
+		if err != nil {
+			return err
+		}
+	}
+
+	secret := gosecret.NewSecret(k.service.Session, nil, value, "application/json")
+	_, err = collection.CreateItem("gh-slack credentials for "+team, secretAttributes(team), secret, true)
+	return err
+}
+
+func (k *secretServiceKeyring) remove(team string) error {
+	items, err := k.items(team)
+	if err != nil {
+		return err
+	}
+
+	if len(items) == 0 {
+		return errNoCredentials
+	}
+
+	for _, item := range items {
+		err = item.Delete()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
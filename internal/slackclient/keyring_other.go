@@ -0,0 +1,11 @@
+//go:build !linux
+
+package slackclient
+
+import "errors"
+
+// openSystemKeyring is only implemented for the Secret Service on Linux, so
+// elsewhere credentials are kept in the encrypted file.
+func openSystemKeyring() (keyring, error) {
+	return nil, errors.New("no supported system keyring")
+}
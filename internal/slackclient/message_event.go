@@ -0,0 +1,138 @@
+package slackclient
+
+// Message event subtypes that change how the event should be interpreted. See
+// https://api.slack.com/events/message#subtypes.
+const (
+	SubtypeBotMessage     = "bot_message"
+	SubtypeMessageChanged = "message_changed"
+	SubtypeMessageDeleted = "message_deleted"
+	SubtypeMessageReplied = "message_replied"
+)
+
+type MessageEventKind int
+
+const (
+	// MessagePosted is a newly posted message, including those posted by
+	// legacy bots with the bot_message subtype.
+	MessagePosted MessageEventKind = iota
+	// MessageEdited is an existing message whose content has changed.
+	MessageEdited
+	// MessageDeleted is an existing message that has been deleted.
+	MessageDeleted
+	// MessageReplied is a thread parent whose replies have changed. The
+	// message content itself is unchanged.
+	MessageReplied
+)
+
+func (k MessageEventKind) String() string {
+	switch k {
+	case MessagePosted:
+		return "posted"
+	case MessageEdited:
+		return "edited"
+	case MessageDeleted:
+		return "deleted"
+	case MessageReplied:
+		return "replied"
+	}
+	return "unknown"
+}
+
+// MessageEvent is a "message" event with any nested payload unwrapped.
+type MessageEvent struct {
+	Kind    MessageEventKind
+	Channel string
+
+	// Message is the message as it is now. It is nil for deletions.
+	Message *RTMEvent
+	// Previous is the message before an edit or deletion, if Slack sent it.
+	Previous *RTMEvent
+}
+
+// AsMessage decodes a "message" event according to its subtype. It returns
+// false for events of other types, and for changes that Slack reports as
+// edits but which leave the message content untouched, such as updates to the
+// reply metadata of a thread parent.
+func (e *RTMEvent) AsMessage() (*MessageEvent, bool) {
+	if e.Type != "message" {
+		return nil, false
+	}
+
+	switch e.Subtype {
+	case SubtypeMessageChanged:
+		if e.Message == nil {
+			return nil, false
+		}
+
+		if e.PreviousMessage != nil && sameContent(e.Message, e.PreviousMessage) {
+			return nil, false
+		}
+
+		return &MessageEvent{
+			Kind:     MessageEdited,
+			Channel:  e.Channel,
+			Message:  withChannel(e.Message, e.Channel),
+			Previous: e.PreviousMessage,
+		}, true
+	case SubtypeMessageDeleted:
+		return &MessageEvent{
+			Kind:     MessageDeleted,
+			Channel:  e.Channel,
+			Previous: e.PreviousMessage,
+		}, true
+	case SubtypeMessageReplied:
+		if e.Message == nil {
+			return nil, false
+		}
+
+		return &MessageEvent{
+			Kind:    MessageReplied,
+			Channel: e.Channel,
+			Message: withChannel(e.Message, e.Channel),
+		}, true
+	}
+
+	return &MessageEvent{
+		Kind:    MessagePosted,
+		Channel: e.Channel,
+		Message: e,
+	}, true
+}
+
+// IsResponse reports whether the event carries new content: either a newly
+// posted message or an edit.
+func (m *MessageEvent) IsResponse() bool {
+	return m.Kind == MessagePosted || m.Kind == MessageEdited
+}
+
+// withChannel returns message with its channel set, as nested messages
+// usually omit it.
+func withChannel(message *RTMEvent, channel string) *RTMEvent {
+	if message.Channel != "" {
+		return message
+	}
+
+	m := *message
+	m.Channel = channel
+	return &m
+}
+
+func sameContent(a, b *RTMEvent) bool {
+	if a.Text != b.Text || len(a.Attachments) != len(b.Attachments) || len(a.Files) != len(b.Files) {
+		return false
+	}
+
+	for i := range a.Attachments {
+		if a.Attachments[i].Text != b.Attachments[i].Text {
+			return false
+		}
+	}
+
+	for i := range a.Files {
+		if a.Files[i].Preview != b.Files[i].Preview {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,115 @@
+package slackclient
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAsMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    string
+		ok       bool
+		kind     MessageEventKind
+		text     string
+		user     string
+		response bool
+	}{
+		{
+			name:     "plain message",
+			event:    `{"type":"message","channel":"C1","user":"U1","text":"hello","ts":"1.1"}`,
+			ok:       true,
+			kind:     MessagePosted,
+			text:     "hello",
+			user:     "U1",
+			response: true,
+		},
+		{
+			name:     "legacy bot message",
+			event:    `{"type":"message","subtype":"bot_message","channel":"C1","bot_id":"B1","username":"robot","text":"beep","ts":"1.1"}`,
+			ok:       true,
+			kind:     MessagePosted,
+			text:     "beep",
+			response: true,
+		},
+		{
+			name: "edit",
+			event: `{"type":"message","subtype":"message_changed","hidden":true,"channel":"C1","ts":"2.2",
+				"message":{"type":"message","user":"U1","text":"done","ts":"1.1"},
+				"previous_message":{"type":"message","user":"U1","text":"in progress","ts":"1.1"}}`,
+			ok:       true,
+			kind:     MessageEdited,
+			text:     "done",
+			user:     "U1",
+			response: true,
+		},
+		{
+			name: "edit without content change",
+			event: `{"type":"message","subtype":"message_changed","hidden":true,"channel":"C1","ts":"2.2",
+				"message":{"type":"message","user":"U1","text":"done","ts":"1.1","reply_count":1},
+				"previous_message":{"type":"message","user":"U1","text":"done","ts":"1.1"}}`,
+			ok: false,
+		},
+		{
+			name: "deletion",
+			event: `{"type":"message","subtype":"message_deleted","hidden":true,"channel":"C1","ts":"2.2","deleted_ts":"1.1",
+				"previous_message":{"type":"message","user":"U1","text":"oops","ts":"1.1"}}`,
+			ok:   true,
+			kind: MessageDeleted,
+		},
+		{
+			name: "reply",
+			event: `{"type":"message","subtype":"message_replied","hidden":true,"channel":"C1","ts":"2.2",
+				"message":{"type":"message","user":"U1","text":"parent","ts":"1.1","thread_ts":"1.1"}}`,
+			ok:   true,
+			kind: MessageReplied,
+			text: "parent",
+			user: "U1",
+		},
+		{
+			name:  "not a message",
+			event: `{"type":"user_typing","channel":"C1","user":"U1"}`,
+			ok:    false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			event := &RTMEvent{}
+			if err := json.Unmarshal([]byte(test.event), event); err != nil {
+				t.Fatal(err)
+			}
+
+			message, ok := event.AsMessage()
+			if ok != test.ok {
+				t.Fatalf("expected ok=%t, got %t", test.ok, ok)
+			}
+			if !ok {
+				return
+			}
+
+			if message.Kind != test.kind {
+				t.Errorf("expected kind %s, got %s", test.kind, message.Kind)
+			}
+
+			if message.Channel != "C1" {
+				t.Errorf("expected channel C1, got %q", message.Channel)
+			}
+
+			if message.IsResponse() != test.response {
+				t.Errorf("expected IsResponse()=%t", test.response)
+			}
+
+			if test.kind == MessageDeleted {
+				if message.Message != nil {
+					t.Errorf("expected no message for deletion, got %+v", message.Message)
+				}
+				return
+			}
+
+			if message.Message.Text != test.text || message.Message.User != test.user || message.Message.Channel != "C1" {
+				t.Errorf("unexpected message: %+v", message.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package slackclient
+
+import "fmt"
+
+// NameStyle selects how users are named in rendered messages.
+type NameStyle string
+
+const (
+	// NameStyleHandle uses the username (handle), e.g. "jdoe".
+	NameStyleHandle NameStyle = "handle"
+	// NameStyleDisplay uses the display name from the user's profile, e.g.
+	// "Jane", falling back to the real name and then the handle.
+	NameStyleDisplay NameStyle = "display"
+	// NameStyleReal uses the user's full name, e.g. "Jane Doe", falling back
+	// to the handle.
+	NameStyleReal NameStyle = "real"
+	// NameStyleBoth uses the full name followed by the handle, e.g.
+	// "Jane Doe (jdoe)".
+	NameStyleBoth NameStyle = "both"
+)
+
+func ParseNameStyle(s string) (NameStyle, error) {
+	switch style := NameStyle(s); style {
+	case NameStyleHandle, NameStyleDisplay, NameStyleReal, NameStyleBoth:
+		return style, nil
+	}
+
+	return "", fmt.Errorf("unknown name style %q, expected one of %q, %q, %q or %q",
+		s, NameStyleHandle, NameStyleDisplay, NameStyleReal, NameStyleBoth)
+}
+
+func (u *User) realName() string {
+	if u.RealName != "" {
+		return u.RealName
+	}
+	return u.Profile.RealName
+}
+
+// NameInStyle returns the user's name in the given style.
+func (u *User) NameInStyle(style NameStyle) string {
+	switch style {
+	case NameStyleDisplay:
+		if u.Profile.DisplayName != "" {
+			return u.Profile.DisplayName
+		}
+		if realName := u.realName(); realName != "" {
+			return realName
+		}
+	case NameStyleReal:
+		if realName := u.realName(); realName != "" {
+			return realName
+		}
+	case NameStyleBoth:
+		if realName := u.realName(); realName != "" && realName != u.Name {
+			return fmt.Sprintf("%s (%s)", realName, u.Name)
+		}
+	}
+
+	return u.Name
+}
+
+// WithNameStyle sets how NameForID names users. The default is the handle.
+func (c *SlackClient) WithNameStyle(style NameStyle) {
+	c.nameStyle = style
+}
+
+// NameForID returns the name of the user with the given ID in the client's
+// name style, for display in rendered messages. Use UsernameForID where the
+// handle is needed.
+func (c *SlackClient) NameForID(id string) (string, error) {
+	user, err := c.UserForID(id)
+	if err != nil {
+		return "", err
+	}
+
+	return user.NameInStyle(c.nameStyle), nil
+}
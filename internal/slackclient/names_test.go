@@ -0,0 +1,33 @@
+package slackclient
+
+import "testing"
+
+func TestNameInStyle(t *testing.T) {
+	jane := User{Name: "jdoe", RealName: "Jane Doe", Profile: UserProfile{DisplayName: "Jane"}}
+	noDisplay := User{Name: "jdoe", Profile: UserProfile{RealName: "Jane Doe"}}
+	handleOnly := User{Name: "jdoe"}
+
+	tests := []struct {
+		user     User
+		style    NameStyle
+		expected string
+	}{
+		{jane, NameStyleHandle, "jdoe"},
+		{jane, NameStyleDisplay, "Jane"},
+		{jane, NameStyleReal, "Jane Doe"},
+		{jane, NameStyleBoth, "Jane Doe (jdoe)"},
+		{jane, "", "jdoe"},
+		{noDisplay, NameStyleDisplay, "Jane Doe"},
+		{noDisplay, NameStyleReal, "Jane Doe"},
+		{handleOnly, NameStyleDisplay, "jdoe"},
+		{handleOnly, NameStyleReal, "jdoe"},
+		{handleOnly, NameStyleBoth, "jdoe"},
+	}
+
+	for _, tt := range tests {
+		actual := tt.user.NameInStyle(tt.style)
+		if actual != tt.expected {
+			t.Errorf("expected %q for %+v in style %q, got %q", tt.expected, tt.user, tt.style, actual)
+		}
+	}
+}
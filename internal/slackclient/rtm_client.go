@@ -2,12 +2,7 @@ package slackclient
 
 import (
 	"context"
-	"fmt"
-	"os"
-	"strings"
-	"time"
 
-	"github.com/cli/go-gh/v2/pkg/markdown"
 	"nhooyr.io/websocket"
 	"nhooyr.io/websocket/wsjson"
 )
@@ -25,86 +20,34 @@ type RTMEvent struct {
 	Type        string       `json:"type"`
 	Channel     string       `json:"channel,omitempty"`
 	User        string       `json:"user,omitempty"`
+	Username    string       `json:"username,omitempty"`
 	Text        string       `json:"text,omitempty"`
 	TS          string       `json:"ts,omitempty"`
+	ThreadTS    string       `json:"thread_ts,omitempty"`
 	BotID       string       `json:"bot_id,omitempty"`
 	BotProfile  BotProfile   `json:"bot_profile,omitempty"`
 	Subtype     string       `json:"subtype,omitempty"`
+	Hidden      bool         `json:"hidden,omitempty"`
 	Attachments []Attachment `json:"attachments,omitempty"`
 	Files       []File       `json:"files,omitempty"`
-}
-
-// correctUser checks if the message is sent by the bot/user that we are waiting
-// for. We accept three possible matches against the user-provided name:
-//   - The bot profile's name (case-insensitive)
-//   - The user's ID (case-sensitive)
-//   - The user's name (case-insensitive)
-func (c *RTMClient) correctUser(message *RTMEvent, botName string) bool {
-	if strings.EqualFold(message.BotProfile.Name, botName) {
-		return true
-	}
-
-	if message.User == botName {
-		return true
-	}
 
-	// It would be nice to just convert botName to an ID and compare that, but
-	// the Slack API doesn't provide a way to do that if botName is not a member
-	// of the team (an outside collaborator). So we have to do this the hard
-	// way.
-	user, err := c.slackClient.UsernameForID(message.User)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		return false
-	}
-
-	return strings.EqualFold(user, botName)
+	// Message and PreviousMessage are set for the message_changed,
+	// message_deleted and message_replied subtypes. See AsMessage.
+	Message         *RTMEvent `json:"message,omitempty"`
+	PreviousMessage *RTMEvent `json:"previous_message,omitempty"`
+	DeletedTS       string    `json:"deleted_ts,omitempty"`
 }
 
-func trimAndPrint(text string) {
-	s, err := markdown.Render(text)
+// NextEvent reads the next event from the RTM websocket.
+func (c *RTMClient) NextEvent(ctx context.Context) (*RTMEvent, error) {
+	event := &RTMEvent{}
+	err := wsjson.Read(ctx, c.conn, event)
 	if err != nil {
-		// This is a bit lazy, but the default configuration of the markdown
-		// renderer cannot fail.
-		panic(err)
-	}
-
-	s = strings.TrimRight(s, " \t\n")
-	if s == "" {
-		return
+		c.conn.Close(websocket.StatusUnsupportedData, "")
+		return nil, err
 	}
 
-	fmt.Printf("%s\n", s)
-}
-
-// ListenForMessagesFromBot listens for the first message from the bot in a given channel and prints its contents
-func (c *RTMClient) ListenForMessagesFromBot(channelID, botName string, timeout time.Duration) error {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-
-	for {
-		message := &RTMEvent{}
-		err := wsjson.Read(ctx, c.conn, &message)
-		if err != nil {
-			c.conn.Close(websocket.StatusUnsupportedData, "")
-			return err
-		}
-
-		if message.Channel == channelID && message.Type == "message" && c.correctUser(message, botName) {
-			trimAndPrint(message.Text)
-
-			for _, attachment := range message.Attachments {
-				trimAndPrint(attachment.Text)
-			}
-
-			for _, file := range message.Files {
-				trimAndPrint(file.Preview)
-			}
-
-			break
-		}
-	}
-	return nil
+	return event, nil
 }
 
 func (c *RTMClient) Close() error {
@@ -0,0 +1,115 @@
+package slackclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// maxSearchPageSize is the most matches search.messages returns in a page.
+const maxSearchPageSize = 100
+
+// SearchSort orders search results.
+type SearchSort string
+
+const (
+	// SearchSortScore orders results by relevance, the most relevant first.
+	SearchSortScore SearchSort = "score"
+	// SearchSortTimestamp orders results by time, the most recent first.
+	SearchSortTimestamp SearchSort = "timestamp"
+)
+
+// ParseSearchSort parses the value of a --sort flag.
+func ParseSearchSort(s string) (SearchSort, error) {
+	switch SearchSort(s) {
+	case SearchSortScore, SearchSortTimestamp:
+		return SearchSort(s), nil
+	}
+	return "", fmt.Errorf("invalid sort %q, expected %q or %q", s, SearchSortScore, SearchSortTimestamp)
+}
+
+type SearchChannel struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	IsPrivate bool   `json:"is_private"`
+	IsIM      bool   `json:"is_im"`
+	IsMpim    bool   `json:"is_mpim"`
+}
+
+// SearchMatch is a message found by SearchMessages.
+type SearchMatch struct {
+	Channel   SearchChannel `json:"channel"`
+	User      string        `json:"user"`
+	Username  string        `json:"username"`
+	Ts        string        `json:"ts"`
+	Text      string        `json:"text"`
+	Permalink string        `json:"permalink"`
+}
+
+type SearchPaging struct {
+	Count int `json:"count"`
+	Total int `json:"total"`
+	Page  int `json:"page"`
+	Pages int `json:"pages"`
+}
+
+type SearchResponse struct {
+	Ok       bool
+	Error    string
+	Query    string
+	Messages struct {
+		Total   int           `json:"total"`
+		Paging  SearchPaging  `json:"paging"`
+		Matches []SearchMatch `json:"matches"`
+	}
+}
+
+// SearchMessages returns up to limit messages matching query, which may use
+// Slack's search modifiers such as in:, from:, after: and has:. It also
+// returns the total number of matches, which may be more than were fetched.
+// Search is not available with bot tokens.
+func (c *SlackClient) SearchMessages(query string, sort SearchSort, limit int) ([]SearchMatch, int, error) {
+	if sort == "" {
+		sort = SearchSortScore
+	}
+
+	count := min(limit, maxSearchPageSize)
+	matches := make([]SearchMatch, 0, count)
+	total := 0
+	for page := 1; len(matches) < limit; page++ {
+		c.log.Printf("Searching for %q, page %d", query, page)
+		body, err := c.get("search.messages", map[string]string{
+			"query":    query,
+			"sort":     string(sort),
+			"sort_dir": "desc",
+			"count":    strconv.Itoa(count),
+			"page":     strconv.Itoa(page),
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+
+		resp := &SearchResponse{}
+		err = json.Unmarshal(body, resp)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if !resp.Ok {
+			return nil, 0, fmt.Errorf("search.messages response not OK: %s", body)
+		}
+
+		matches = append(matches, resp.Messages.Matches...)
+		total = resp.Messages.Total
+
+		if len(resp.Messages.Matches) == 0 || page >= resp.Messages.Paging.Pages {
+			break
+		}
+	}
+
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	return matches, total, nil
+}
@@ -0,0 +1,42 @@
+package slackclient_test
+
+import (
+	"testing"
+
+	"github.com/rneatherway/gh-slack/internal/mocks"
+	"github.com/rneatherway/gh-slack/internal/slackclient"
+)
+
+func TestSearchMessagesPages(t *testing.T) {
+	mockClient := &mocks.MockClient{}
+	var calls []string
+	mockClient.MockResponses(map[string]string{
+		"search.messages?count=100&page=1&query=deploy&sort=timestamp&sort_dir=desc": `{"ok":true,"messages":{"total":3,"paging":{"count":100,"total":3,"page":1,"pages":2},"matches":[
+			{"channel":{"id":"C1","name":"ops"},"user":"U1","username":"alice","ts":"1709663536.325529","text":"deploying","permalink":"https://example.slack.com/archives/C1/p1709663536325529"},
+			{"channel":{"id":"C1","name":"ops"},"user":"U2","username":"bob","ts":"1709663500.000001","text":"deploy done"}]}}`,
+		"search.messages?count=100&page=2&query=deploy&sort=timestamp&sort_dir=desc": `{"ok":true,"messages":{"total":3,"paging":{"count":100,"total":3,"page":2,"pages":2},"matches":[
+			{"channel":{"id":"C2","name":"dev"},"user":"U1","username":"alice","ts":"1709663400.000001","text":"deploy?"}]}}`,
+	}, &calls)
+
+	client, err := slackclient.Null("test", mockClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matches, total, err := client.SearchMessages("deploy", slackclient.SearchSortTimestamp, 150)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if total != 3 || len(matches) != 3 || len(calls) != 2 {
+		t.Fatalf("expected 3 matches from 2 pages, got %d of %d from %d", len(matches), total, len(calls))
+	}
+	if matches[0].Permalink != "https://example.slack.com/archives/C1/p1709663536325529" || matches[2].Channel.Name != "dev" {
+		t.Errorf("unexpected matches %+v", matches)
+	}
+
+	matches, _, err = client.SearchMessages("deploy", slackclient.SearchSortTimestamp, 1)
+	if err == nil {
+		t.Errorf("expected an error for a page that was not mocked, got %+v", matches)
+	}
+}
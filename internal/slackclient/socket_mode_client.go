@@ -0,0 +1,72 @@
+package slackclient
+
+import (
+	"context"
+	"fmt"
+
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+// SocketModeClient receives events over a Socket Mode connection. Unlike the
+// RTM API, Socket Mode is available to all Slack apps, but requires an
+// app-level token.
+type SocketModeClient struct {
+	conn        *websocket.Conn
+	slackClient *SlackClient
+}
+
+type AppsConnectionsOpenResponse struct {
+	Ok    bool   `json:"ok"`
+	Error string `json:"error"`
+	URL   string `json:"url"`
+}
+
+// socketModeEnvelope wraps every message sent by Slack over a Socket Mode
+// connection. Envelopes with an ID must be acknowledged, otherwise Slack will
+// redeliver them.
+type socketModeEnvelope struct {
+	EnvelopeID string `json:"envelope_id,omitempty"`
+	Type       string `json:"type"`
+	Reason     string `json:"reason,omitempty"`
+	Payload    struct {
+		Event RTMEvent `json:"event"`
+	} `json:"payload"`
+}
+
+type socketModeAck struct {
+	EnvelopeID string `json:"envelope_id"`
+}
+
+// NextEvent reads envelopes from the connection, acknowledging each of them,
+// until one containing an Events API event arrives.
+func (c *SocketModeClient) NextEvent(ctx context.Context) (*RTMEvent, error) {
+	for {
+		envelope := &socketModeEnvelope{}
+		err := wsjson.Read(ctx, c.conn, envelope)
+		if err != nil {
+			c.conn.Close(websocket.StatusUnsupportedData, "")
+			return nil, err
+		}
+
+		if envelope.EnvelopeID != "" {
+			err = wsjson.Write(ctx, c.conn, &socketModeAck{EnvelopeID: envelope.EnvelopeID})
+			if err != nil {
+				return nil, fmt.Errorf("failed to acknowledge envelope %q: %w", envelope.EnvelopeID, err)
+			}
+		}
+
+		switch envelope.Type {
+		case "events_api":
+			return &envelope.Payload.Event, nil
+		case "disconnect":
+			return nil, fmt.Errorf("socket mode connection closed by Slack: %s", envelope.Reason)
+		default:
+			c.slackClient.log.Printf("Ignoring socket mode envelope of type %q", envelope.Type)
+		}
+	}
+}
+
+func (c *SocketModeClient) Close() error {
+	return c.conn.Close(websocket.StatusNormalClosure, "")
+}
@@ -0,0 +1,83 @@
+package slackclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+func TestSocketModeClientAcknowledgesEnvelopes(t *testing.T) {
+	acks := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close(websocket.StatusNormalClosure, "")
+
+		ctx := r.Context()
+		for _, msg := range []string{
+			`{"type":"hello"}`,
+			`{"envelope_id":"1","type":"slash_commands","payload":{}}`,
+			`{"envelope_id":"2","type":"events_api","payload":{"event":{"type":"message","channel":"C1","text":"hi"}}}`,
+		} {
+			if err := conn.Write(ctx, websocket.MessageText, []byte(msg)); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		for i := 0; i < 2; i++ {
+			ack := &socketModeAck{}
+			if err := wsjson.Read(ctx, conn, ack); err != nil {
+				t.Error(err)
+				return
+			}
+			acks <- ack.EnvelopeID
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slackClient, err := Null("test", http.DefaultTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &SocketModeClient{conn: conn, slackClient: slackClient}
+	defer client.Close()
+
+	event, err := client.NextEvent(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if event.Type != "message" || event.Channel != "C1" || event.Text != "hi" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+
+	for _, expected := range []string{"1", "2"} {
+		select {
+		case actual := <-acks:
+			if actual != expected {
+				t.Errorf("expected ack for envelope %q, got %q", expected, actual)
+			}
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for ack of envelope %q", expected)
+		}
+	}
+}
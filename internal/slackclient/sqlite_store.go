@@ -0,0 +1,449 @@
+package slackclient
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteMigrations are applied in order to bring the database schema up to
+// date. The number applied so far is recorded in the user_version pragma, so
+// existing entries must never be changed, only appended to.
+var sqliteMigrations = []string{
+	`CREATE TABLE team (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		team_id TEXT NOT NULL,
+		enterprise_id TEXT NOT NULL
+	);
+	CREATE TABLE users (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		display_name TEXT NOT NULL,
+		email TEXT NOT NULL,
+		fetched_at INTEGER NOT NULL,
+		data TEXT NOT NULL
+	);
+	CREATE INDEX users_name ON users (name);
+	CREATE INDEX users_display_name ON users (display_name);
+	CREATE INDEX users_email ON users (email);
+	CREATE TABLE channels (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		fetched_at INTEGER NOT NULL,
+		data TEXT NOT NULL
+	);
+	CREATE INDEX channels_name ON channels (name);
+	CREATE TABLE bots (
+		id TEXT PRIMARY KEY,
+		fetched_at INTEGER NOT NULL,
+		data TEXT NOT NULL
+	);`,
+}
+
+// sqliteStore keeps the cache in an SQLite database. The full records are
+// stored as JSON, alongside indexed columns for the fields we look them up by.
+// SQLite handles locking between processes, and as with jsonStore an entry is
+// only replaced by one fetched at the same time or later.
+type sqliteStore struct {
+	path string
+	db   *sql.DB
+}
+
+func sqliteStorePath(teamDir string) string {
+	return path.Join(teamDir, "cache.db")
+}
+
+// openSQLiteStore opens the database at the given path, creating it if
+// necessary. A new database is populated from the contents of the given store,
+// if any.
+func openSQLiteStore(dbPath string, from cacheStore) (*sqliteStore, error) {
+	err := os.MkdirAll(path.Dir(dbPath), 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = os.Stat(dbPath)
+	created := errors.Is(err, os.ErrNotExist)
+
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &sqliteStore{path: dbPath, db: db}
+	err = s.migrate()
+	if err == nil && created && from != nil {
+		err = s.importFrom(from)
+	}
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open cache %q: %w", dbPath, err)
+	}
+
+	return s, nil
+}
+
+func (s *sqliteStore) migrate() error {
+	var version int
+	err := s.db.QueryRow("PRAGMA user_version").Scan(&version)
+	if err != nil {
+		return err
+	}
+
+	if version > len(sqliteMigrations) {
+		return fmt.Errorf("cache schema version %d is newer than this version of gh-slack supports", version)
+	}
+
+	for i := version; i < len(sqliteMigrations); i++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(sqliteMigrations[i])
+		if err == nil {
+			// PRAGMA does not accept bound parameters.
+			_, err = tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1))
+		}
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to migrate cache schema to version %d: %w", i+1, err)
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *sqliteStore) importFrom(from cacheStore) error {
+	cache, err := from.Contents()
+	if err != nil {
+		return err
+	}
+
+	if cache.TeamID != "" {
+		err = s.SetTeam(cache.TeamID, cache.EnterpriseID)
+		if err != nil {
+			return err
+		}
+	}
+
+	users := make([]CachedUser, 0, len(cache.Users))
+	for _, user := range cache.Users {
+		users = append(users, user)
+	}
+	err = s.PutUsers(users...)
+	if err != nil {
+		return err
+	}
+
+	channels := make([]CachedChannel, 0, len(cache.Channels))
+	for _, channel := range cache.Channels {
+		channels = append(channels, channel)
+	}
+	err = s.PutChannels(channels...)
+	if err != nil {
+		return err
+	}
+
+	for _, bot := range cache.Bots {
+		err = s.PutBot(bot)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// toUnixMilli and fromUnixMilli store the zero time, used for entries that
+// must be refreshed before use, as zero.
+func toUnixMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+func fromUnixMilli(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+func (s *sqliteStore) Path() string {
+	return s.path
+}
+
+func (s *sqliteStore) Team() (string, string, error) {
+	var teamID, enterpriseID string
+	err := s.db.QueryRow("SELECT team_id, enterprise_id FROM team").Scan(&teamID, &enterpriseID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", nil
+	}
+	return teamID, enterpriseID, err
+}
+
+func (s *sqliteStore) SetTeam(teamID, enterpriseID string) error {
+	_, err := s.db.Exec("INSERT OR REPLACE INTO team (id, team_id, enterprise_id) VALUES (1, ?, ?)", teamID, enterpriseID)
+	return err
+}
+
+func (s *sqliteStore) queryUser(where string, arg string) (*CachedUser, error) {
+	var data string
+	var fetchedAt int64
+	err := s.db.QueryRow("SELECT data, fetched_at FROM users WHERE "+where+" LIMIT 1", arg).Scan(&data, &fetchedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	user := &CachedUser{FetchedAt: fromUnixMilli(fetchedAt)}
+	return user, json.Unmarshal([]byte(data), &user.User)
+}
+
+func (s *sqliteStore) User(id string) (*CachedUser, error) {
+	return s.queryUser("id = ?", id)
+}
+
+func (s *sqliteStore) UserByName(name string) (*CachedUser, error) {
+	return s.queryUser("name = ?", name)
+}
+
+func (s *sqliteStore) PutUsers(users ...CachedUser) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, user := range users {
+		data, err := json.Marshal(user.User)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`INSERT INTO users (id, name, display_name, email, fetched_at, data)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET
+				name = excluded.name, display_name = excluded.display_name, email = excluded.email,
+				fetched_at = excluded.fetched_at, data = excluded.data
+			WHERE excluded.fetched_at >= users.fetched_at`,
+			user.ID, user.Name, user.Profile.DisplayName, user.Profile.Email, toUnixMilli(user.FetchedAt), string(data))
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *sqliteStore) queryChannel(where string, arg string) (*CachedChannel, error) {
+	var data string
+	var fetchedAt int64
+	err := s.db.QueryRow("SELECT data, fetched_at FROM channels WHERE "+where+" LIMIT 1", arg).Scan(&data, &fetchedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	channel := &CachedChannel{FetchedAt: fromUnixMilli(fetchedAt)}
+	return channel, json.Unmarshal([]byte(data), &channel.Channel)
+}
+
+func (s *sqliteStore) Channel(id string) (*CachedChannel, error) {
+	return s.queryChannel("id = ?", id)
+}
+
+func (s *sqliteStore) ChannelByName(name string) (*CachedChannel, error) {
+	return s.queryChannel("name = ?", name)
+}
+
+func (s *sqliteStore) PutChannels(channels ...CachedChannel) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, channel := range channels {
+		data, err := json.Marshal(channel.Channel)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`INSERT INTO channels (id, name, fetched_at, data)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET
+				name = excluded.name, fetched_at = excluded.fetched_at, data = excluded.data
+			WHERE excluded.fetched_at >= channels.fetched_at`,
+			channel.ID, channel.Name, toUnixMilli(channel.FetchedAt), string(data))
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *sqliteStore) DeleteChannel(id string) error {
+	_, err := s.db.Exec("DELETE FROM channels WHERE id = ?", id)
+	return err
+}
+
+func (s *sqliteStore) Bot(id string) (*CachedBot, error) {
+	var data string
+	var fetchedAt int64
+	err := s.db.QueryRow("SELECT data, fetched_at FROM bots WHERE id = ?", id).Scan(&data, &fetchedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	bot := &CachedBot{FetchedAt: fromUnixMilli(fetchedAt)}
+	return bot, json.Unmarshal([]byte(data), &bot.BotProfile)
+}
+
+func (s *sqliteStore) PutBot(bot CachedBot) error {
+	data, err := json.Marshal(bot.BotProfile)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`INSERT INTO bots (id, fetched_at, data)
+		VALUES (?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET fetched_at = excluded.fetched_at, data = excluded.data
+		WHERE excluded.fetched_at >= bots.fetched_at`,
+		bot.ID, toUnixMilli(bot.FetchedAt), string(data))
+	return err
+}
+
+func (s *sqliteStore) Contents() (Cache, error) {
+	cache := newCache()
+
+	var err error
+	cache.TeamID, cache.EnterpriseID, err = s.Team()
+	if err != nil {
+		return cache, err
+	}
+
+	err = s.scanAll("SELECT data, fetched_at FROM users", func(rows *sql.Rows) error {
+		user := CachedUser{}
+		err := scanCached(rows, &user.FetchedAt, &user.User)
+		if err != nil {
+			return err
+		}
+		cache.Users[user.ID] = user
+		return nil
+	})
+	if err != nil {
+		return cache, err
+	}
+
+	err = s.scanAll("SELECT data, fetched_at FROM channels", func(rows *sql.Rows) error {
+		channel := CachedChannel{}
+		err := scanCached(rows, &channel.FetchedAt, &channel.Channel)
+		if err != nil {
+			return err
+		}
+		cache.Channels[channel.ID] = channel
+		return nil
+	})
+	if err != nil {
+		return cache, err
+	}
+
+	err = s.scanAll("SELECT data, fetched_at FROM bots", func(rows *sql.Rows) error {
+		bot := CachedBot{}
+		err := scanCached(rows, &bot.FetchedAt, &bot.BotProfile)
+		if err != nil {
+			return err
+		}
+		cache.Bots[bot.ID] = bot
+		return nil
+	})
+	if err != nil {
+		return cache, err
+	}
+
+	return cache, nil
+}
+
+// scanAll calls scan for each row returned by query, stopping at the first
+// error from either.
+func (s *sqliteStore) scanAll(query string, scan func(*sql.Rows) error) error {
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		err = scan(rows)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// scanCached reads a row of data and fetched_at columns into a record and its
+// fetch time.
+func scanCached(rows *sql.Rows, fetchedAt *time.Time, record any) error {
+	var data string
+	var ms int64
+	err := rows.Scan(&data, &ms)
+	if err != nil {
+		return err
+	}
+
+	*fetchedAt = fromUnixMilli(ms)
+	return json.Unmarshal([]byte(data), record)
+}
+
+func (s *sqliteStore) Clear(users, channels bool) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var statements []string
+	if users {
+		statements = append(statements, "DELETE FROM users", "DELETE FROM bots")
+	}
+	if channels {
+		statements = append(statements, "DELETE FROM channels")
+	}
+	if users && channels {
+		statements = append(statements, "DELETE FROM team")
+	}
+
+	for _, statement := range statements {
+		_, err = tx.Exec(statement)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
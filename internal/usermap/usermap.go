@@ -0,0 +1,86 @@
+// Package usermap maps Slack users to GitHub users.
+package usermap
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rneatherway/gh-slack/internal/slackclient"
+	"gopkg.in/yaml.v3"
+)
+
+// Map maps Slack users to GitHub logins. Users can be mapped explicitly, by
+// their Slack user ID, handle or email address, or by matching their email
+// address against the emails of GitHub users.
+type Map struct {
+	logins map[string]string
+	emails map[string]string
+}
+
+func New() *Map {
+	return &Map{
+		logins: map[string]string{},
+		emails: map[string]string{},
+	}
+}
+
+// Add maps the Slack user with the given ID, handle or email address to a
+// GitHub login. A leading "@" on either is ignored.
+func (m *Map) Add(slackUser, login string) {
+	m.logins[strings.ToLower(strings.TrimPrefix(slackUser, "@"))] = strings.TrimPrefix(login, "@")
+}
+
+// AddFile adds the mappings in a YAML file, which maps Slack users (as for
+// Add) to GitHub logins:
+//
+//	jdoe: octocat
+//	U012AB3CD: hubot
+//	jane@example.com: monalisa
+func (m *Map) AddFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	logins := map[string]string{}
+	err = yaml.Unmarshal(content, &logins)
+	if err != nil {
+		return fmt.Errorf("failed to parse GitHub users file %q: %w", path, err)
+	}
+
+	for slackUser, login := range logins {
+		m.Add(slackUser, login)
+	}
+
+	return nil
+}
+
+// AddEmails adds the email addresses of GitHub users, keyed by email, which
+// are matched against the emails in Slack profiles.
+func (m *Map) AddEmails(emails map[string]string) {
+	for email, login := range emails {
+		m.emails[strings.ToLower(email)] = login
+	}
+}
+
+// Len returns the number of mappings and emails.
+func (m *Map) Len() int {
+	return len(m.logins) + len(m.emails)
+}
+
+// Login returns the GitHub login of the given Slack user, if known.
+func (m *Map) Login(user *slackclient.User) (string, bool) {
+	email := strings.ToLower(user.Profile.Email)
+	for _, key := range []string{strings.ToLower(user.ID), strings.ToLower(user.Name), email} {
+		if login, ok := m.logins[key]; ok && key != "" {
+			return login, true
+		}
+	}
+
+	if login, ok := m.emails[email]; ok && email != "" {
+		return login, true
+	}
+
+	return "", false
+}
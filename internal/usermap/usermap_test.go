@@ -0,0 +1,43 @@
+package usermap
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/rneatherway/gh-slack/internal/slackclient"
+)
+
+func TestLogin(t *testing.T) {
+	file := path.Join(t.TempDir(), "github-users.yml")
+	err := os.WriteFile(file, []byte("jdoe: octocat\nU2: '@hubot'\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := New()
+	err = m.AddFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Add("Jane@Example.com", "monalisa")
+	m.AddEmails(map[string]string{"bob@example.com": "bob-gh"})
+
+	tests := []struct {
+		user     slackclient.User
+		expected string
+	}{
+		{slackclient.User{ID: "U1", Name: "jdoe"}, "octocat"},
+		{slackclient.User{ID: "U2", Name: "robot"}, "hubot"},
+		{slackclient.User{ID: "U3", Name: "jane", Profile: slackclient.UserProfile{Email: "jane@example.com"}}, "monalisa"},
+		{slackclient.User{ID: "U4", Name: "bob", Profile: slackclient.UserProfile{Email: "Bob@example.com"}}, "bob-gh"},
+		{slackclient.User{ID: "U5", Name: "nobody"}, ""},
+	}
+
+	for _, tt := range tests {
+		actual, ok := m.Login(&tt.user)
+		if actual != tt.expected || ok != (tt.expected != "") {
+			t.Errorf("expected %q for %+v, got %q", tt.expected, tt.user, actual)
+		}
+	}
+}